@@ -4,7 +4,7 @@ import * as classic from "@pulumi/awsx/classic";
 
 const vpc = classic.ec2.Vpc.getDefault();
 
-const cluster = new classic.ecs.Cluster("cluster", {
+const cluster = new ecs.Cluster("cluster", {
     capacityProviders: ["FARGATE_SPOT"],
     defaultCapacityProviderStrategies: [
         {
@@ -29,7 +29,7 @@ const fargateTask = new ecs.FargateTaskDefinition("fargate-task", {
 });
 
 const service = new ecs.FargateService("my-service", {
-    cluster: cluster.cluster.arn,
+    cluster: cluster.clusterArn,
     taskDefinition: fargateTask.taskDefinition.arn,
     loadBalancers: fargateTask.loadBalancers,
     networkConfiguration: {
@@ -24,14 +24,18 @@ import (
 
 var resourceConstructorMap = map[string]ResourceConstructor{
 	resources.TrailIdentifier:                   createNewResourceConstructor(resources.NewTrail),
+	resources.BastionIdentifier:                 createNewResourceConstructor(resources.NewBastion),
 	resources.DefaultVPCIdentifier:              createNewResourceConstructor(resources.NewDefaultVPC),
 	resources.VPCIdentifier:                     createNewResourceConstructor(resources.NewVPC),
 	resources.ImageIdentifier:                   createNewResourceConstructor(resources.NewImage),
 	resources.RepositoryIdentifier:              createNewResourceConstructor(resources.NewRepository),
+	resources.ClusterIdentifier:                 createNewResourceConstructor(resources.NewCluster),
+	resources.EC2CapacityProviderIdentifier:     createNewResourceConstructor(resources.NewEC2CapacityProvider),
 	resources.EC2ServiceIdentifier:              createNewResourceConstructor(resources.NewEC2Service),
 	resources.EC2TaskDefinitionIdentifier:       createNewResourceConstructor(resources.NewEC2TaskDefinition),
 	resources.FargateServiceIdentifier:          createNewResourceConstructor(resources.NewFargateService),
 	resources.FargateTaskDefinitionIdentifier:   createNewResourceConstructor(resources.NewFargateTaskDefinition),
+	resources.ScheduledTaskIdentifier:           createNewResourceConstructor(resources.NewScheduledTask),
 	resources.ApplicationLoadBalancerIdentifier: createNewResourceConstructor(resources.NewApplicationLoadBalancer),
 	resources.NetworkLoadBalancerIdentifier:     createNewResourceConstructor(resources.NewNetworkLoadBalancer),
 	resources.TargetGroupAttachmentIdentifier:   createNewResourceConstructor(resources.NewTargetGroupAttachment),
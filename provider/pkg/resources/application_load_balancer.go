@@ -150,7 +150,7 @@ func NewApplicationLoadBalancer(ctx *pulumi.Context, name string, args *Applicat
 		}
 		component.VpcID = pulumi.String(firstSubnet.VpcId).ToStringOutput()
 	} else {
-		defaultVPC, err := getDefaultVPC(ctx, pulumi.Parent(component))
+		defaultVPC, err := getDefaultVPC(ctx, nil, pulumi.Parent(component))
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,346 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const BastionIdentifier = "awsx-go:ec2:Bastion"
+
+type BastionAutoStopInputs struct {
+	Schedule string `pulumi:"schedule"`
+}
+
+type BastionPortForwardInputs struct {
+	LocalPort  int    `pulumi:"localPort"`
+	RemoteHost string `pulumi:"remoteHost"`
+	RemotePort int    `pulumi:"remotePort"`
+}
+
+type BastionArgs struct {
+	AllowedSSHCidrBlocks     []string                    `pulumi:"allowedSshCidrBlocks"`
+	AMI                      string                      `pulumi:"ami"`
+	Architecture             string                      `pulumi:"architecture"`
+	AssociatePublicIPAddress bool                        `pulumi:"associatePublicIpAddress"`
+	AutoStop                 *BastionAutoStopInputs      `pulumi:"autoStop"`
+	InstanceRole             DefaultRoleWithPolicyInputs `pulumi:"instanceRole"`
+	InstanceType             string                      `pulumi:"instanceType"`
+	KeyName                  string                      `pulumi:"keyName"`
+	PortForward              *BastionPortForwardInputs   `pulumi:"portForward"`
+	SecurityGroupIDs         []string                    `pulumi:"securityGroupIds"`
+	SubnetIDs                pulumi.StringArrayInput     `pulumi:"subnetIds"`
+	Tags                     map[string]string           `pulumi:"tags"`
+	VpcID                    pulumi.StringInput          `pulumi:"vpcId"`
+}
+
+type Bastion struct {
+	pulumi.ResourceState
+
+	Instance           *ec2.Instance        `pulumi:"instance"`
+	InstanceID         pulumi.StringOutput  `pulumi:"instanceId"`
+	InstanceProfile    *iam.InstanceProfile `pulumi:"instanceProfile"`
+	PortForwardCommand pulumi.StringOutput  `pulumi:"portForwardCommand"`
+	Role               *iam.Role            `pulumi:"role"`
+	SecurityGroup      *ec2.SecurityGroup   `pulumi:"securityGroup"`
+	SessionCommand     pulumi.StringOutput  `pulumi:"sessionCommand"`
+}
+
+func NewBastion(ctx *pulumi.Context, name string, args *BastionArgs, opts ...pulumi.ResourceOption) (*Bastion, error) {
+	if args == nil {
+		args = &BastionArgs{}
+	}
+
+	component := &Bastion{}
+	err := ctx.RegisterComponentResource(BastionIdentifier, name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, pulumi.Parent(component))
+
+	architecture := args.Architecture
+	if architecture == "" {
+		architecture = "x86_64"
+	}
+
+	if architecture != "x86_64" && architecture != "arm64" {
+		return nil, fmt.Errorf("Unknown bastion architecture %s. Must be one of [x86_64] or [arm64]", architecture)
+	}
+
+	if args.PortForward != nil && args.PortForward.RemotePort == 0 {
+		return nil, fmt.Errorf("[portForward] [remotePort] must be specified")
+	}
+
+	ami := args.AMI
+	if ami == "" {
+		parameter, err := ssm.LookupParameter(ctx, &ssm.LookupParameterArgs{
+			Name: fmt.Sprintf("/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-%s", architecture),
+		}, pulumi.Parent(component))
+		if err != nil {
+			return nil, err
+		}
+
+		ami = parameter.Value
+	}
+
+	instanceType := args.InstanceType
+	if instanceType == "" {
+		instanceType = "t3.micro"
+		if architecture == "arm64" {
+			instanceType = "t4g.micro"
+		}
+	}
+
+	var ingress ec2.SecurityGroupIngressArray
+	if len(args.AllowedSSHCidrBlocks) > 0 {
+		ingress = append(ingress, &ec2.SecurityGroupIngressArgs{
+			FromPort:   pulumi.Int(22),
+			ToPort:     pulumi.Int(22),
+			Protocol:   pulumi.String("tcp"),
+			CidrBlocks: pulumi.ToStringArray(args.AllowedSSHCidrBlocks),
+		})
+	}
+
+	// Without NAT, the default VPC only reaches Systems Manager through a public IP.
+	network, err := instanceNetwork(ctx, name, &instanceNetworkInputs{
+		AssociatePublicIPAddress: args.AssociatePublicIPAddress,
+		Ingress:                  ingress,
+		SecurityGroupIDs:         args.SecurityGroupIDs,
+		SubnetIDs:                args.SubnetIDs,
+		Tags:                     args.Tags,
+		VpcID:                    args.VpcID,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.SecurityGroup = network.SecurityGroup
+
+	if args.InstanceRole.RoleARN == "" && args.InstanceRole.Args == nil {
+		args.InstanceRole.Args = &RoleWithPolicyInputs{}
+	}
+
+	if args.InstanceRole.Args != nil && len(args.InstanceRole.Args.PolicyARNs) == 0 {
+		args.InstanceRole.Args.PolicyARNs = defaultBastionRolePolicyARNs()
+	}
+
+	if args.InstanceRole.Skip {
+		return nil, fmt.Errorf("[instanceRole] cannot be skipped, the bastion requires a role for Systems Manager")
+	}
+
+	assumeRolePolicy, err := serviceAssumeRolePolicy(ctx, "ec2.amazonaws.com")
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := defaultRoleWithPolicies(ctx, name, args.InstanceRole, assumeRolePolicy.Json, opts...)
+	if err != nil {
+		return nil, err
+	}
+	component.Role = role.Role
+
+	instanceProfile, err := iam.NewInstanceProfile(ctx, name, &iam.InstanceProfileArgs{
+		Role: role.RoleName(),
+		Tags: pulumi.ToStringMap(args.Tags),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	component.InstanceProfile = instanceProfile
+
+	instanceTags := map[string]string{
+		"Name": name,
+	}
+	for tagKey, tagValue := range args.Tags {
+		instanceTags[tagKey] = tagValue
+	}
+
+	var keyName pulumi.StringPtrInput
+	if args.KeyName != "" {
+		keyName = pulumi.StringPtr(args.KeyName)
+	}
+
+	instance, err := ec2.NewInstance(ctx, name, &ec2.InstanceArgs{
+		Ami:                      pulumi.StringPtr(ami),
+		AssociatePublicIpAddress: pulumi.BoolPtr(network.AssociatePublicIPAddress),
+		IamInstanceProfile:       instanceProfile.Name,
+		InstanceType:             pulumi.StringPtr(instanceType),
+		KeyName:                  keyName,
+		MetadataOptions: &ec2.InstanceMetadataOptionsArgs{
+			HttpEndpoint: pulumi.StringPtr("enabled"),
+			HttpTokens:   pulumi.StringPtr("required"),
+		},
+		RootBlockDevice: &ec2.InstanceRootBlockDeviceArgs{
+			Encrypted: pulumi.BoolPtr(true),
+		},
+		SubnetId:            network.SubnetIDs.ToStringArrayOutput().Index(pulumi.Int(0)),
+		Tags:                pulumi.ToStringMap(instanceTags),
+		VpcSecurityGroupIds: network.SecurityGroupIDs,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.Instance = instance
+	component.InstanceID = instance.ID().ToStringOutput()
+
+	if args.AutoStop != nil {
+		err = bastionAutoStop(ctx, name, args.AutoStop, instance, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	region, err := aws.GetRegion(ctx, nil, invokeOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.SessionCommand = pulumi.Sprintf("aws ssm start-session --target %s --region %s", instance.ID(), region.Name)
+	component.PortForwardCommand = pulumi.String("").ToStringOutput()
+	if args.PortForward != nil {
+		component.PortForwardCommand = instance.ID().ToStringOutput().ApplyT(func(instanceID string) (string, error) {
+			return bastionPortForwardCommand(instanceID, region.Name, args.PortForward)
+		}).(pulumi.StringOutput)
+	}
+
+	if err := ctx.RegisterResourceOutputs(component, pulumi.Map{
+		"instanceId":         component.InstanceID,
+		"portForwardCommand": component.PortForwardCommand,
+		"sessionCommand":     component.SessionCommand,
+	}); err != nil {
+		return nil, err
+	}
+
+	return component, nil
+}
+
+func defaultBastionRolePolicyARNs() []string {
+	return []string{
+		"arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore",
+	}
+}
+
+func bastionPortForwardCommand(instanceID, region string, inputs *BastionPortForwardInputs) (string, error) {
+	localPort := inputs.LocalPort
+	if localPort == 0 {
+		localPort = inputs.RemotePort
+	}
+
+	documentName := "AWS-StartPortForwardingSession"
+	parameters := map[string][]string{
+		"portNumber":      {fmt.Sprintf("%v", inputs.RemotePort)},
+		"localPortNumber": {fmt.Sprintf("%v", localPort)},
+	}
+
+	if inputs.RemoteHost != "" {
+		documentName = "AWS-StartPortForwardingSessionToRemoteHost"
+		parameters["host"] = []string{inputs.RemoteHost}
+	}
+
+	parametersJSON, err := json.Marshal(parameters)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("aws ssm start-session --target %s --region %s --document-name %s --parameters '%s'", instanceID, region, documentName, string(parametersJSON)), nil
+}
+
+// bastionAutoStop stops the bastion instance on a schedule by running the AWS-StopEC2Instance
+// Systems Manager automation from an EventBridge rule.
+func bastionAutoStop(ctx *pulumi.Context, name string, inputs *BastionAutoStopInputs, instance *ec2.Instance, opts ...pulumi.ResourceOption) error {
+	schedule := inputs.Schedule
+	if schedule == "" {
+		schedule = "cron(0 20 * * ? *)"
+	}
+
+	prefix, err := arnPrefix(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	automationDefinitionARN := fmt.Sprintf("%s:automation-definition/AWS-StopEC2Instance", prefix.For("ssm"))
+
+	assumeRolePolicy, err := serviceAssumeRolePolicy(ctx, "events.amazonaws.com")
+	if err != nil {
+		return err
+	}
+
+	autoStopName := fmt.Sprintf("%s-auto-stop", name)
+	role, err := iam.NewRole(ctx, autoStopName, &iam.RoleArgs{
+		AssumeRolePolicy: pulumi.String(assumeRolePolicy.Json),
+		InlinePolicies: iam.RoleInlinePolicyArray{
+			&iam.RoleInlinePolicyArgs{
+				Name: pulumi.String("stop-bastion"),
+				Policy: instance.Arn.ApplyT(func(instanceARN string) (string, error) {
+					return allowPolicyDocument(ctx, []iam.GetPolicyDocumentStatement{
+						{
+							Actions:   []string{"ssm:StartAutomationExecution"},
+							Resources: []string{fmt.Sprintf("%s:*", automationDefinitionARN)},
+						},
+						{
+							Actions:   []string{"ec2:StopInstances"},
+							Resources: []string{instanceARN},
+						},
+						{
+							Actions:   []string{"ec2:DescribeInstanceStatus"},
+							Resources: []string{"*"},
+						},
+					})
+				}).(pulumi.StringOutput),
+			},
+		},
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	rule, err := cloudwatch.NewEventRule(ctx, autoStopName, &cloudwatch.EventRuleArgs{
+		ScheduleExpression: pulumi.StringPtr(schedule),
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	_, err = cloudwatch.NewEventTarget(ctx, autoStopName, &cloudwatch.EventTargetArgs{
+		Arn:     pulumi.String(fmt.Sprintf("%s:$DEFAULT", automationDefinitionARN)),
+		Rule:    rule.Name,
+		RoleArn: role.Arn,
+		Input: instance.ID().ToStringOutput().ApplyT(func(instanceID string) (string, error) {
+			input, err := json.Marshal(map[string][]string{
+				"InstanceId": {instanceID},
+			})
+			if err != nil {
+				return "", err
+			}
+
+			return string(input), nil
+		}).(pulumi.StringOutput),
+	}, append(opts, pulumi.Parent(rule))...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
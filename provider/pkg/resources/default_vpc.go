@@ -4,14 +4,18 @@ import "github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 
 const DefaultVPCIdentifier = "awsx-go:ec2:DefaultVpc"
 
-type DefaultVPCArgs struct{}
+type DefaultVPCArgs struct {
+	AvailabilityZoneNames []string `pulumi:"availabilityZoneNames"`
+}
 
 type DefaultVPC struct {
 	pulumi.ResourceState
 
-	VPCID            pulumi.StringOutput      `pulumi:"vpcId"`
-	PrivateSubnetIDs pulumi.StringArrayOutput `pulumi:"privateSubnetIds"`
-	PublicSubnetIDs  pulumi.StringArrayOutput `pulumi:"publicSubnetIds"`
+	AvailabilityZones []DefaultVPCAvailabilityZone `pulumi:"availabilityZones"`
+	Subnets           []DefaultVPCSubnet           `pulumi:"subnets"`
+	VPCID             pulumi.StringOutput          `pulumi:"vpcId"`
+	PrivateSubnetIDs  pulumi.StringArrayOutput     `pulumi:"privateSubnetIds"`
+	PublicSubnetIDs   pulumi.StringArrayOutput     `pulumi:"publicSubnetIds"`
 }
 
 func NewDefaultVPC(ctx *pulumi.Context, name string, args *DefaultVPCArgs, opts ...pulumi.ResourceOption) (*DefaultVPC, error) {
@@ -25,11 +29,13 @@ func NewDefaultVPC(ctx *pulumi.Context, name string, args *DefaultVPCArgs, opts
 		return nil, err
 	}
 
-	defaultVPC, err := getDefaultVPC(ctx)
+	defaultVPC, err := getDefaultVPC(ctx, args.AvailabilityZoneNames, pulumi.Parent(component))
 	if err != nil {
 		return nil, err
 	}
 
+	component.AvailabilityZones = defaultVPC.AvailabilityZones
+	component.Subnets = defaultVPC.Subnets
 	component.VPCID = defaultVPC.VPCID
 	component.PublicSubnetIDs = defaultVPC.PublicSubnetIDs
 	component.PrivateSubnetIDs = defaultVPC.PrivateSubnetIDs
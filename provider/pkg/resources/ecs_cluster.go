@@ -0,0 +1,234 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/kms"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/zchase/pulumi-awsx-go/pkg/utils"
+)
+
+const ClusterIdentifier = "awsx-go:ecs:Cluster"
+
+type ClusterCapacityProviderStrategyInputs struct {
+	Base             int    `pulumi:"base"`
+	CapacityProvider string `pulumi:"capacityProvider"`
+	Weight           int    `pulumi:"weight"`
+}
+
+type ClusterExecuteCommandInputs struct {
+	KMSKeyID     string                `pulumi:"kmsKeyId"`
+	LogGroup     DefaultLogGroupInputs `pulumi:"logGroup"`
+	S3BucketName string                `pulumi:"s3BucketName"`
+	S3KeyPrefix  string                `pulumi:"s3KeyPrefix"`
+	Skip         bool                  `pulumi:"skip"`
+}
+
+type ClusterArgs struct {
+	CapacityProviders                 []string                                `pulumi:"capacityProviders"`
+	ContainerInsights                 string                                  `pulumi:"containerInsights"`
+	DefaultCapacityProviderStrategies []ClusterCapacityProviderStrategyInputs `pulumi:"defaultCapacityProviderStrategies"`
+	ExecuteCommand                    ClusterExecuteCommandInputs             `pulumi:"executeCommand"`
+	Name                              string                                  `pulumi:"name"`
+	Tags                              map[string]string                       `pulumi:"tags"`
+}
+
+type Cluster struct {
+	pulumi.ResourceState
+
+	CapacityProviders          *ecs.ClusterCapacityProviders `pulumi:"capacityProviders"`
+	Cluster                    *ecs.Cluster                  `pulumi:"cluster"`
+	ClusterARN                 pulumi.StringOutput           `pulumi:"clusterArn"`
+	ClusterName                pulumi.StringOutput           `pulumi:"clusterName"`
+	ExecuteCommandKey          *kms.Key                      `pulumi:"executeCommandKey"`
+	ExecuteCommandKMSKeyID     pulumi.StringOutput           `pulumi:"executeCommandKmsKeyId"`
+	ExecuteCommandLogGroup     *cloudwatch.LogGroup          `pulumi:"executeCommandLogGroup"`
+	ExecuteCommandLogGroupName pulumi.StringOutput           `pulumi:"executeCommandLogGroupName"`
+}
+
+func NewCluster(ctx *pulumi.Context, name string, args *ClusterArgs, opts ...pulumi.ResourceOption) (*Cluster, error) {
+	if args == nil {
+		args = &ClusterArgs{}
+	}
+
+	component := &Cluster{}
+	err := ctx.RegisterComponentResource(ClusterIdentifier, name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, pulumi.Parent(component))
+
+	containerInsights := strings.ToLower(args.ContainerInsights)
+	if containerInsights == "" {
+		containerInsights = "enabled"
+	}
+
+	if containerInsights != "enabled" && containerInsights != "disabled" {
+		return nil, fmt.Errorf("Unknown Container Insights setting %s", args.ContainerInsights)
+	}
+
+	capacityProviders := args.CapacityProviders
+	strategies := args.DefaultCapacityProviderStrategies
+	if len(capacityProviders) == 0 {
+		capacityProviders = []string{"FARGATE", "FARGATE_SPOT"}
+
+		if len(strategies) == 0 {
+			strategies = []ClusterCapacityProviderStrategyInputs{
+				{
+					CapacityProvider: "FARGATE",
+					Weight:           1,
+				},
+			}
+		}
+	}
+
+	err = validateCapacityProviderStrategies(capacityProviders, strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterArgs := &ecs.ClusterArgs{
+		Settings: ecs.ClusterSettingArray{
+			&ecs.ClusterSettingArgs{
+				Name:  pulumi.String("containerInsights"),
+				Value: pulumi.String(containerInsights),
+			},
+		},
+		Tags: pulumi.ToStringMap(args.Tags),
+	}
+
+	if args.Name != "" {
+		clusterArgs.Name = pulumi.StringPtr(args.Name)
+	}
+
+	component.ExecuteCommandKMSKeyID = pulumi.String("").ToStringOutput()
+	component.ExecuteCommandLogGroupName = pulumi.String("").ToStringOutput()
+	if !args.ExecuteCommand.Skip {
+		executeCommandConfiguration, err := clusterExecuteCommandConfiguration(ctx, name, component, &args.ExecuteCommand, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		clusterArgs.Configuration = &ecs.ClusterConfigurationArgs{
+			ExecuteCommandConfiguration: executeCommandConfiguration,
+		}
+	}
+
+	cluster, err := ecs.NewCluster(ctx, name, clusterArgs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultStrategies ecs.ClusterCapacityProvidersDefaultCapacityProviderStrategyArray
+	for _, strategy := range strategies {
+		defaultStrategies = append(defaultStrategies, &ecs.ClusterCapacityProvidersDefaultCapacityProviderStrategyArgs{
+			Base:             pulumi.IntPtr(strategy.Base),
+			CapacityProvider: pulumi.String(strategy.CapacityProvider),
+			Weight:           pulumi.IntPtr(strategy.Weight),
+		})
+	}
+
+	clusterCapacityProviders, err := ecs.NewClusterCapacityProviders(ctx, name, &ecs.ClusterCapacityProvidersArgs{
+		ClusterName:                       cluster.Name,
+		CapacityProviders:                 pulumi.ToStringArray(capacityProviders),
+		DefaultCapacityProviderStrategies: defaultStrategies,
+	}, pulumi.Parent(cluster), pulumi.DependsOn([]pulumi.Resource{cluster}))
+	if err != nil {
+		return nil, err
+	}
+
+	component.CapacityProviders = clusterCapacityProviders
+	component.Cluster = cluster
+	component.ClusterARN = cluster.Arn
+	component.ClusterName = cluster.Name
+
+	return component, nil
+}
+
+func validateCapacityProviderStrategies(capacityProviders []string, strategies []ClusterCapacityProviderStrategyInputs) error {
+	hasBase := false
+	for _, strategy := range strategies {
+		if strategy.Base > 0 {
+			if hasBase {
+				return fmt.Errorf("Only one capacity provider strategy can have a [base] defined")
+			}
+
+			hasBase = true
+		}
+
+		isRegistered := false
+		for _, capacityProvider := range capacityProviders {
+			if capacityProvider == strategy.CapacityProvider {
+				isRegistered = true
+			}
+		}
+
+		if !isRegistered {
+			return fmt.Errorf("Capacity provider %s is used in a strategy but is not one of %s", strategy.CapacityProvider, strings.Join(capacityProviders, ", "))
+		}
+	}
+
+	return nil
+}
+
+// clusterExecuteCommandConfiguration builds the ECS Exec configuration of the cluster. Sessions are
+// encrypted with the given KMS key, or with a key created for the cluster, and logged to a log group
+// unless the log group is skipped, and to an S3 bucket if one is given.
+func clusterExecuteCommandConfiguration(ctx *pulumi.Context, name string, component *Cluster, inputs *ClusterExecuteCommandInputs, opts ...pulumi.ResourceOption) (*ecs.ClusterConfigurationExecuteCommandConfigurationArgs, error) {
+	kmsKeyID := pulumi.String(inputs.KMSKeyID).ToStringOutput()
+	if inputs.KMSKeyID == "" {
+		key, err := kms.NewKey(ctx, name, &kms.KeyArgs{
+			Description:       pulumi.Sprintf("Encrypts ECS Exec sessions of cluster %s", name),
+			EnableKeyRotation: pulumi.BoolPtr(true),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.ExecuteCommandKey = key
+		kmsKeyID = key.Arn
+	}
+
+	component.ExecuteCommandKMSKeyID = kmsKeyID
+
+	if inputs.LogGroup.Skip && inputs.S3BucketName == "" {
+		return &ecs.ClusterConfigurationExecuteCommandConfigurationArgs{
+			KmsKeyId: kmsKeyID,
+			Logging:  pulumi.String("DEFAULT"),
+		}, nil
+	}
+
+	logConfiguration := &ecs.ClusterConfigurationExecuteCommandConfigurationLogConfigurationArgs{}
+	if inputs.S3BucketName != "" {
+		logConfiguration.S3BucketName = pulumi.StringPtr(inputs.S3BucketName)
+		if inputs.S3KeyPrefix != "" {
+			logConfiguration.S3KeyPrefix = pulumi.StringPtr(inputs.S3KeyPrefix)
+		}
+	}
+
+	if !inputs.LogGroup.Skip {
+		logGroup, err := requiredLogGroup(ctx, fmt.Sprintf("%s-exec", name), &LogGroupArgs{
+			Args:     inputs.LogGroup.Args,
+			Existing: inputs.LogGroup.Existing,
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.ExecuteCommandLogGroup = logGroup.LogGroup
+		component.ExecuteCommandLogGroupName = utils.ApplyAny(logGroup.LogGroupID, func(logGroupID LogGroupID) pulumi.StringOutput {
+			return logGroupID.LogGroupName
+		})
+		logConfiguration.CloudWatchLogGroupName = component.ExecuteCommandLogGroupName.ToStringPtrOutput()
+	}
+
+	return &ecs.ClusterConfigurationExecuteCommandConfigurationArgs{
+		KmsKeyId:         kmsKeyID,
+		Logging:          pulumi.String("OVERRIDE"),
+		LogConfiguration: logConfiguration,
+	}, nil
+}
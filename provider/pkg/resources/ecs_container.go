@@ -1,6 +1,9 @@
 package resources
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -8,160 +11,213 @@ import (
 )
 
 type TaskDefinitionContainerDependencyInputs struct {
-	Condition     string `pulumi:"condition"`
-	ContainerName string `pulumi:"containerName"`
+	Condition     string `pulumi:"condition" json:"condition,omitempty"`
+	ContainerName string `pulumi:"containerName" json:"containerName,omitempty"`
 }
 
 type TaskDefinitionKeyValuePairInputs struct {
-	Name  string `pulumi:"name"`
-	Value string `pulumi:"value"`
+	Name  string `pulumi:"name" json:"name,omitempty"`
+	Value string `pulumi:"value" json:"value,omitempty"`
 }
 
 type TaskDefinitionEnvironmentFileInputs struct {
-	Type  string `pulumi:"type"`
-	Value string `pulumi:"value"`
+	Type  string `pulumi:"type" json:"type,omitempty"`
+	Value string `pulumi:"value" json:"value,omitempty"`
 }
 
 type TaskDefinitionHostEntryInputs struct {
-	Hostname  string `pulumi:"hostname"`
-	IPAddress string `pulumi:"ipAddress"`
+	Hostname  string `pulumi:"hostname" json:"hostname,omitempty"`
+	IPAddress string `pulumi:"ipAddress" json:"ipAddress,omitempty"`
 }
 
 type TaskDefinitionFirelensConfigurationInputs struct {
-	Options interface{} `pulumi:"options"`
-	Type    string      `pulumi:"type"`
+	Options interface{} `pulumi:"options" json:"options,omitempty"`
+	Type    string      `pulumi:"type" json:"type,omitempty"`
 }
 
 type TaskDefinitionHealthCheckInputs struct {
-	Command     []string `pulumi:"command"`
-	Interval    int      `pulumi:"interval"`
-	Retries     int      `pulumi:"retries"`
-	StartPeriod int      `pulumi:"startPeriod"`
-	Timeout     int      `pulumi:"timeout"`
+	Command     []string `pulumi:"command" json:"command,omitempty"`
+	Interval    int      `pulumi:"interval" json:"interval,omitempty"`
+	Retries     int      `pulumi:"retries" json:"retries,omitempty"`
+	StartPeriod int      `pulumi:"startPeriod" json:"startPeriod,omitempty"`
+	Timeout     int      `pulumi:"timeout" json:"timeout,omitempty"`
 }
 
 type TaskDefinitionKernelCapabilitiesInputs struct {
-	Add  []string `pulumi:"add"`
-	Drop []string `pulumi:"drop"`
+	Add  []string `pulumi:"add" json:"add,omitempty"`
+	Drop []string `pulumi:"drop" json:"drop,omitempty"`
 }
 
 type TaskDefinitionDeviceInputs struct {
-	ContainerPath string   `pulumi:"containerPath"`
-	HostPath      string   `pulumi:"hostPath"`
-	Permissions   []string `pulumi:"permissions"`
+	ContainerPath string   `pulumi:"containerPath" json:"containerPath,omitempty"`
+	HostPath      string   `pulumi:"hostPath" json:"hostPath,omitempty"`
+	Permissions   []string `pulumi:"permissions" json:"permissions,omitempty"`
 }
 
 type TaskDefinitionTmpfsInputs struct {
-	ContainerPath string   `pulumi:"containerPath"`
-	MountOptions  []string `pulumi:"mountOptions"`
-	Size          int      `pulumi:"size"`
+	ContainerPath string   `pulumi:"containerPath" json:"containerPath,omitempty"`
+	MountOptions  []string `pulumi:"mountOptions" json:"mountOptions,omitempty"`
+	Size          int      `pulumi:"size" json:"size,omitempty"`
 }
 
 type TaskDefinitionLinuxParametersInputs struct {
-	Capabilities       TaskDefinitionKernelCapabilitiesInputs `pulumi:"capabilities"`
-	Devices            []TaskDefinitionDeviceInputs           `pulumi:"devices"`
-	InitProcessEnabled bool                                   `pulumi:"initProcessEnabled"`
-	MaxSwap            int                                    `pulumi:"maxSwap"`
-	SharedMemorySize   int                                    `pulumi:"sharedMemorySize"`
-	Swappiness         int                                    `pulumi:"swappiness"`
-	TMPFS              TaskDefinitionTmpfsInputs              `pulumi:"tmpfs"`
+	Capabilities       *TaskDefinitionKernelCapabilitiesInputs `pulumi:"capabilities" json:"capabilities,omitempty"`
+	Devices            []TaskDefinitionDeviceInputs            `pulumi:"devices" json:"devices,omitempty"`
+	InitProcessEnabled bool                                    `pulumi:"initProcessEnabled" json:"initProcessEnabled,omitempty"`
+	MaxSwap            int                                     `pulumi:"maxSwap" json:"maxSwap,omitempty"`
+	SharedMemorySize   int                                     `pulumi:"sharedMemorySize" json:"sharedMemorySize,omitempty"`
+	Swappiness         int                                     `pulumi:"swappiness" json:"swappiness,omitempty"`
+	TMPFS              []TaskDefinitionTmpfsInputs             `pulumi:"tmpfs" json:"tmpfs,omitempty"`
 }
 
 type TaskDefinitionSecretInputs struct {
-	Name      string `pulumi:"name"`
-	ValueFrom string `pulumi:"valueFrom"`
+	JSONKey      string             `pulumi:"jsonKey"`
+	KMSKeyID     string             `pulumi:"kmsKeyId"`
+	Name         string             `pulumi:"name"`
+	Value        pulumi.StringInput `pulumi:"value"`
+	ValueFrom    pulumi.StringInput `pulumi:"valueFrom"`
+	VersionID    string             `pulumi:"versionId"`
+	VersionStage string             `pulumi:"versionStage"`
 }
 
 type TaskDefinitionLogConfigurationInputs struct {
-	LogDriver     string                       `pulumi:"logDriver"`
-	Options       interface{}                  `pulumi:"options"`
-	SecretOptions []TaskDefinitionSecretInputs `pulumi:"secretOptions"`
+	LogDriver     string                       `pulumi:"logDriver" json:"logDriver,omitempty"`
+	Options       interface{}                  `pulumi:"options" json:"options,omitempty"`
+	SecretOptions []TaskDefinitionSecretInputs `pulumi:"secretOptions" json:"secretOptions,omitempty"`
 }
 
 type TaskDefinitionMountPointInputs struct {
-	ContainerPath string `pulumi:"containerPath"`
-	ReadOnly      bool   `pulumi:"readOnly"`
-	SourceVolume  string `pulumi:"sourceVolume"`
+	ContainerPath string `pulumi:"containerPath" json:"containerPath,omitempty"`
+	ReadOnly      bool   `pulumi:"readOnly" json:"readOnly,omitempty"`
+	SourceVolume  string `pulumi:"sourceVolume" json:"sourceVolume,omitempty"`
 }
 
 type TaskDefinitionPortMappingInputs struct {
-	ContainerPort pulumi.IntPtrInput `pulumi:"containerPort"`
-	HostPort      pulumi.IntPtrInput `pulumi:"hostPort"`
-	Protocol      pulumi.String      `pulumi:"protocol"`
-	TargetGroup   *lb.TargetGroup    `pulumi:"targetGroup"`
+	AppProtocol    string             `pulumi:"appProtocol"`
+	ContainerPort  pulumi.IntPtrInput `pulumi:"containerPort"`
+	HostPort       pulumi.IntPtrInput `pulumi:"hostPort"`
+	Name           string             `pulumi:"name"`
+	Protocol       pulumi.String      `pulumi:"protocol"`
+	TargetGroup    *lb.TargetGroup    `pulumi:"targetGroup"`
+	TargetGroupArn pulumi.StringInput `pulumi:"targetGroupArn"`
+}
+
+// TargetGroupARN returns the ARN of the target group the port is registered with, or nil if the port is
+// not load balanced.
+func (m TaskDefinitionPortMappingInputs) TargetGroupARN() pulumi.StringInput {
+	if m.TargetGroup != nil {
+		return m.TargetGroup.Arn
+	}
+
+	return m.TargetGroupArn
 }
 
 type TaskDefinitionRepositoryCredentialsInputs struct {
-	CredentialsParameter string `pulumi:"credentialsParameter"`
+	CredentialsParameter string `pulumi:"credentialsParameter" json:"credentialsParameter,omitempty"`
 }
 
 type TaskDefinitionResourceRequirementInputs struct {
-	Type  string `pulumi:"type"`
-	Value string `pulumi:"value"`
+	Type  string `pulumi:"type" json:"type,omitempty"`
+	Value string `pulumi:"value" json:"value,omitempty"`
 }
 
 type TaskDefinitionSystemControlInputs struct {
-	Namespace string `pulumi:"namespace"`
-	Value     string `pulumi:"value"`
+	Namespace string `pulumi:"namespace" json:"namespace,omitempty"`
+	Value     string `pulumi:"value" json:"value,omitempty"`
 }
 
 type TaskDefinitionUlimitInputs struct {
-	HardLimit int    `pulumi:"hardLimit"`
-	Name      string `pulumi:"name"`
-	SoftLimit int    `pulumi:"softLimit"`
+	HardLimit int    `pulumi:"hardLimit" json:"hardLimit,omitempty"`
+	Name      string `pulumi:"name" json:"name,omitempty"`
+	SoftLimit int    `pulumi:"softLimit" json:"softLimit,omitempty"`
 }
 
 type TaskDefinitionVolumeFromInputs struct {
-	ReadOnly        bool   `pulumi:"readOnly"`
-	SourceContainer string `pulumi:"sourceContainer"`
+	ReadOnly        bool   `pulumi:"readOnly" json:"readOnly,omitempty"`
+	SourceContainer string `pulumi:"sourceContainer" json:"sourceContainer,omitempty"`
 }
 
 type TaskDefinitionContainerDefinitionInputs struct {
-	Command                []string                                  `pulumi:"command"`
-	CPU                    int                                       `pulumi:"cpu"`
-	DependsOn              []TaskDefinitionContainerDependencyInputs `pulumi:"dependsOn"`
-	DisableNetworking      bool                                      `pulumi:"disableNetworking"`
-	DnsSearchDomains       []string                                  `pulumi:"dnsSearchDomains"`
-	DnsServers             []string                                  `pulumi:"dnsServers"`
-	DockerLabels           map[string]string                         `pulumi:"dockerLabels"`
-	DockerSecurityOptions  []string                                  `pulumi:"dockerSecurityOptions"`
-	EntryPoint             []string                                  `pulumi:"entryPoint"`
-	Environment            []TaskDefinitionKeyValuePairInputs        `pulumi:"environment"`
-	EnvironmentFiles       []TaskDefinitionEnvironmentFileInputs     `pulumi:"environmentFiles"`
-	Essential              bool                                      `pulumi:"essential"`
-	ExtraHosts             []TaskDefinitionHostEntryInputs           `pulumi:"extraHosts"`
-	FirelensConfiguration  TaskDefinitionFirelensConfigurationInputs `pulumi:"firelensConfiguration"`
-	HealthCheck            TaskDefinitionHealthCheckInputs           `pulumi:"healthCheck"`
-	Hostname               string                                    `pulumi:"hostname"`
-	Image                  string                                    `pulumi:"image"`
-	Interactive            bool                                      `pulumi:"interactive"`
-	Links                  []string                                  `pulumi:"links"`
-	LinuxParameters        TaskDefinitionLinuxParametersInputs       `pulumi:"linuxParameters"`
-	LogConfiguration       *TaskDefinitionLogConfigurationInputs     `pulumi:"logConfiguration"`
-	Memory                 int                                       `pulumi:"memory"`
-	MemoryReservation      int                                       `pulumi:"memoryReservation"`
-	MountPoints            []TaskDefinitionMountPointInputs          `pulumi:"mountPoints"`
-	Name                   string                                    `pulumi:"name"`
-	PortMappings           []TaskDefinitionPortMappingInputs         `pulumi:"portMappings"`
-	Privileged             bool                                      `pulumi:"privileged"`
-	PseudoTerminal         bool                                      `pulumi:"pseudoTerminal"`
-	ReadonlyRootFilesystem bool                                      `pulumi:"readonlyRootFilesystem"`
-	RepositoryCredentials  TaskDefinitionRepositoryCredentialsInputs `pulumi:"repositoryCredentials"`
-	ResourceRequirements   []TaskDefinitionResourceRequirementInputs `pulumi:"resourceRequirements"`
-	Secrets                []TaskDefinitionSecretInputs              `pulumi:"secrets"`
-	StartTimeout           int                                       `pulumi:"startTimeout"`
-	StopTimeout            int                                       `pulumi:"stopTimeout"`
-	SystemControls         []TaskDefinitionSystemControlInputs       `pulumi:"systemControls"`
-	Ulimits                []TaskDefinitionUlimitInputs              `pulumi:"ulimits"`
-	User                   string                                    `pulumi:"user"`
-	VolumesFrom            []TaskDefinitionVolumeFromInputs          `pulumi:"volumesFrom"`
-	WorkingDirectory       string                                    `pulumi:" workingDirectory"`
+	Command                []string                                   `pulumi:"command" json:"command,omitempty"`
+	CPU                    int                                        `pulumi:"cpu" json:"cpu,omitempty"`
+	DependsOn              []TaskDefinitionContainerDependencyInputs  `pulumi:"dependsOn" json:"dependsOn,omitempty"`
+	DisableNetworking      bool                                       `pulumi:"disableNetworking" json:"disableNetworking,omitempty"`
+	DnsSearchDomains       []string                                   `pulumi:"dnsSearchDomains" json:"dnsSearchDomains,omitempty"`
+	DnsServers             []string                                   `pulumi:"dnsServers" json:"dnsServers,omitempty"`
+	DockerLabels           map[string]string                          `pulumi:"dockerLabels" json:"dockerLabels,omitempty"`
+	DockerSecurityOptions  []string                                   `pulumi:"dockerSecurityOptions" json:"dockerSecurityOptions,omitempty"`
+	EntryPoint             []string                                   `pulumi:"entryPoint" json:"entryPoint,omitempty"`
+	Environment            []TaskDefinitionKeyValuePairInputs         `pulumi:"environment" json:"environment,omitempty"`
+	EnvironmentFiles       []TaskDefinitionEnvironmentFileInputs      `pulumi:"environmentFiles" json:"environmentFiles,omitempty"`
+	Essential              *bool                                      `pulumi:"essential" json:"essential,omitempty"`
+	ExtraHosts             []TaskDefinitionHostEntryInputs            `pulumi:"extraHosts" json:"extraHosts,omitempty"`
+	FirelensConfiguration  *TaskDefinitionFirelensConfigurationInputs `pulumi:"firelensConfiguration" json:"firelensConfiguration,omitempty"`
+	HealthCheck            *TaskDefinitionHealthCheckInputs           `pulumi:"healthCheck" json:"healthCheck,omitempty"`
+	Hostname               string                                     `pulumi:"hostname" json:"hostname,omitempty"`
+	Image                  string                                     `pulumi:"image" json:"image,omitempty"`
+	ImageBuild             *TaskDefinitionContainerImageBuildInputs   `pulumi:"imageBuild" json:"-"`
+	Interactive            bool                                       `pulumi:"interactive" json:"interactive,omitempty"`
+	Links                  []string                                   `pulumi:"links" json:"links,omitempty"`
+	LinuxParameters        *TaskDefinitionLinuxParametersInputs       `pulumi:"linuxParameters" json:"linuxParameters,omitempty"`
+	LogConfiguration       *TaskDefinitionLogConfigurationInputs      `pulumi:"logConfiguration" json:"logConfiguration,omitempty"`
+	Memory                 int                                        `pulumi:"memory" json:"memory,omitempty"`
+	MemoryReservation      int                                        `pulumi:"memoryReservation" json:"memoryReservation,omitempty"`
+	MountPoints            []TaskDefinitionMountPointInputs           `pulumi:"mountPoints" json:"mountPoints,omitempty"`
+	Name                   string                                     `pulumi:"name" json:"name,omitempty"`
+	PortMappings           []TaskDefinitionPortMappingInputs          `pulumi:"portMappings" json:"portMappings,omitempty"`
+	Privileged             bool                                       `pulumi:"privileged" json:"privileged,omitempty"`
+	PseudoTerminal         bool                                       `pulumi:"pseudoTerminal" json:"pseudoTerminal,omitempty"`
+	ReadonlyRootFilesystem bool                                       `pulumi:"readonlyRootFilesystem" json:"readonlyRootFilesystem,omitempty"`
+	RepositoryCredentials  *TaskDefinitionRepositoryCredentialsInputs `pulumi:"repositoryCredentials" json:"repositoryCredentials,omitempty"`
+	ResourceRequirements   []TaskDefinitionResourceRequirementInputs  `pulumi:"resourceRequirements" json:"resourceRequirements,omitempty"`
+	Secrets                []TaskDefinitionSecretInputs               `pulumi:"secrets" json:"secrets,omitempty"`
+	StartTimeout           int                                        `pulumi:"startTimeout" json:"startTimeout,omitempty"`
+	StopTimeout            int                                        `pulumi:"stopTimeout" json:"stopTimeout,omitempty"`
+	SystemControls         []TaskDefinitionSystemControlInputs        `pulumi:"systemControls" json:"systemControls,omitempty"`
+	Ulimits                []TaskDefinitionUlimitInputs               `pulumi:"ulimits" json:"ulimits,omitempty"`
+	User                   string                                     `pulumi:"user" json:"user,omitempty"`
+	VolumesFrom            []TaskDefinitionVolumeFromInputs           `pulumi:"volumesFrom" json:"volumesFrom,omitempty"`
+	WorkingDirectory       string                                     `pulumi:"workingDirectory" json:"workingDirectory,omitempty"`
+
+	// builtImage is the pushed image of an [imageBuild].
+	builtImage pulumi.StringOutput
+}
+
+// resolveContainers returns the containers of a task definition, where a single container is named
+// after the task definition.
+func resolveContainers(name string, container *TaskDefinitionContainerDefinitionInputs, containers map[string]TaskDefinitionContainerDefinitionInputs) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+	if container != nil && len(containers) > 0 {
+		return nil, fmt.Errorf("Only one of `container` or `containers` can be provided.")
+	}
+
+	if container == nil && len(containers) == 0 {
+		return nil, fmt.Errorf("Either `container` or `containers` must be provided.")
+	}
+
+	if container != nil {
+		containers = map[string]TaskDefinitionContainerDefinitionInputs{
+			name: *container,
+		}
+	}
+
+	err := validatePortMappings(containers)
+	if err != nil {
+		return nil, err
+	}
+
+	return containers, nil
 }
 
 func computeContainerDefinitions(parent pulumi.Resource, containers map[string]TaskDefinitionContainerDefinitionInputs, logGroupID *pulumi.AnyOutput) []TaskDefinitionContainerDefinitionInputs {
+	var containerNames []string
+	for containerName := range containers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
 	var result []TaskDefinitionContainerDefinitionInputs
-	for containerName, container := range containers {
-		result = append(result, computeContainerDefinition(parent, containerName, container, logGroupID))
+	for _, containerName := range containerNames {
+		result = append(result, computeContainerDefinition(parent, containerName, containers[containerName], logGroupID))
 	}
 	return result
 }
@@ -169,37 +225,31 @@ func computeContainerDefinitions(parent pulumi.Resource, containers map[string]T
 func computeContainerDefinition(parent pulumi.Resource, containerName string, container TaskDefinitionContainerDefinitionInputs, logGroupID *pulumi.AnyOutput) TaskDefinitionContainerDefinitionInputs {
 	var resolvedMappings []TaskDefinitionPortMappingInputs
 	for _, mappingInput := range container.PortMappings {
-		containerPort := pulumi.All(mappingInput.ContainerPort, mappingInput.TargetGroup.Port, mappingInput.HostPort).ApplyT(func(args []interface{}) *int {
-			containerPort := args[0].(*int)
-			targetGroupPort := args[1].(*int)
-			hostPort := args[2].(*int)
-
-			if containerPort != nil {
-				return containerPort
-			}
-
-			if targetGroupPort != nil {
-				return targetGroupPort
+		// The ports of a mapping default to the port of its target group.
+		containerPort := mappingInput.ContainerPort
+		hostPort := mappingInput.HostPort
+		if mappingInput.TargetGroup != nil {
+			if containerPort == nil {
+				containerPort = mappingInput.TargetGroup.Port
 			}
 
-			return hostPort
-		}).(pulumi.IntPtrInput)
-
-		hostPort := pulumi.All(mappingInput.TargetGroup.Port, mappingInput.HostPort).ApplyT(func(args []interface{}) *int {
-			targetGroupPort := args[0].(*int)
-			hostPort := args[1].(*int)
-
-			if targetGroupPort != nil {
-				return targetGroupPort
+			if hostPort == nil {
+				hostPort = mappingInput.TargetGroup.Port
 			}
+		}
 
-			return hostPort
-		}).(pulumi.IntPtrInput)
+		if containerPort == nil {
+			containerPort = hostPort
+		}
 
 		resolvedMappings = append(resolvedMappings, TaskDefinitionPortMappingInputs{
-			ContainerPort: containerPort,
-			HostPort:      hostPort,
-			Protocol:      mappingInput.Protocol,
+			AppProtocol:    mappingInput.AppProtocol,
+			ContainerPort:  containerPort,
+			HostPort:       hostPort,
+			Name:           mappingInput.Name,
+			Protocol:       mappingInput.Protocol,
+			TargetGroup:    mappingInput.TargetGroup,
+			TargetGroupArn: mappingInput.TargetGroupArn,
 		})
 	}
 
@@ -208,7 +258,7 @@ func computeContainerDefinition(parent pulumi.Resource, containerName string, co
 
 	if container.LogConfiguration == nil && logGroupID != nil {
 		container.LogConfiguration = &TaskDefinitionLogConfigurationInputs{
-			LogDriver: "awsLogs",
+			LogDriver: "awslogs",
 			Options: map[string]pulumi.StringInput{
 				"awslogs-group": utils.ApplyAny(*logGroupID, func(l LogGroupID) pulumi.StringOutput {
 					return l.LogGroupName
@@ -224,45 +274,59 @@ func computeContainerDefinition(parent pulumi.Resource, containerName string, co
 	return container
 }
 
-type mappedContainerDef struct {
-	ContainerName string
-	TGArn         pulumi.StringOutput
-	TGPort        pulumi.IntPtrOutput
-}
-
-func computeLoadBalancers(ctx *pulumi.Context, containers map[string]TaskDefinitionContainerDefinitionInputs) pulumi.AnyOutput {
-	var mappedContainers []mappedContainerDef
-	for containerName, containerDefinition := range containers {
-		portMappings := containerDefinition.PortMappings
-
-		if len(portMappings) == 0 {
-			return pulumi.AnyOutput{}
-		}
+// computeLoadBalancers registers the service with the target group of every load balanced port of the
+// containers.
+func computeLoadBalancers(containerDefinitions []TaskDefinitionContainerDefinitionInputs) ecs.ServiceLoadBalancerArrayOutput {
+	var loadBalancers ecs.ServiceLoadBalancerArray
+	for _, containerDefinition := range containerDefinitions {
+		for _, mapping := range containerDefinition.PortMappings {
+			targetGroupARN := mapping.TargetGroupARN()
+			if targetGroupARN == nil {
+				continue
+			}
 
-		for _, mapping := range portMappings {
-			mappedContainers = append(mappedContainers, mappedContainerDef{
-				ContainerName: containerName,
-				TGArn:         mapping.TargetGroup.Arn,
-				TGPort:        mapping.TargetGroup.Port,
+			loadBalancers = append(loadBalancers, &ecs.ServiceLoadBalancerArgs{
+				ContainerName:  pulumi.String(containerDefinition.Name),
+				ContainerPort:  mapping.ContainerPort.ToIntPtrOutput().Elem(),
+				TargetGroupArn: targetGroupARN.ToStringOutput().ToStringPtrOutput(),
 			})
 		}
 	}
 
-	result, _, _ := ctx.NewOutput()
-	return result.ApplyT(func(x interface{}) []ecs.ServiceLoadBalancerOutput {
-		lbs := x.([]ecs.ServiceLoadBalancerOutput)
-		for _, containerGroup := range mappedContainers {
-			slb := pulumi.All(containerGroup.TGArn, containerGroup.TGPort).ApplyT(func(tgArn string, tgPort int) ecs.ServiceLoadBalancer {
-				return ecs.ServiceLoadBalancer{
-					ContainerName:  containerGroup.ContainerName,
-					TargetGroupArn: &tgArn,
-					ContainerPort:  tgPort,
+	return loadBalancers.ToServiceLoadBalancerArrayOutput()
+}
+
+// validatePortMappings checks that the port of every mapping is known and that named port settings are
+// complete.
+func validatePortMappings(containers map[string]TaskDefinitionContainerDefinitionInputs) error {
+	for containerName, container := range containers {
+		names := map[string]bool{}
+		for _, mapping := range container.PortMappings {
+			if mapping.TargetGroup != nil && mapping.TargetGroupArn != nil {
+				return fmt.Errorf("Container %s: only one of [targetGroup] or [targetGroupArn] can be specified in a port mapping", containerName)
+			}
+
+			if mapping.ContainerPort == nil && mapping.HostPort == nil && mapping.TargetGroup == nil {
+				return fmt.Errorf("Container %s: port mappings without a [targetGroup] require a [containerPort]", containerName)
+			}
+
+			if mapping.TargetGroupArn != nil && mapping.ContainerPort == nil {
+				return fmt.Errorf("Container %s: port mappings with a [targetGroupArn] require a [containerPort]", containerName)
+			}
+
+			if mapping.AppProtocol != "" && mapping.Name == "" {
+				return fmt.Errorf("Container %s: port mappings with an [appProtocol] require a [name]", containerName)
+			}
+
+			if mapping.Name != "" {
+				if names[mapping.Name] {
+					return fmt.Errorf("Container %s: port mapping name %s is used more than once", containerName, mapping.Name)
 				}
-			}).(ecs.ServiceLoadBalancerOutput)
 
-			lbs = append(lbs, slb)
+				names[mapping.Name] = true
+			}
 		}
+	}
 
-		return lbs
-	}).(pulumi.AnyOutput)
+	return nil
 }
@@ -0,0 +1,248 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type renderedPortMapping struct {
+	AppProtocol   string `json:"appProtocol,omitempty"`
+	ContainerPort *int   `json:"containerPort,omitempty"`
+	HostPort      *int   `json:"hostPort,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+type renderedSecret struct {
+	Name      string `json:"name"`
+	ValueFrom string `json:"valueFrom"`
+}
+
+type renderedLogConfiguration struct {
+	LogDriver     string                 `json:"logDriver"`
+	Options       map[string]interface{} `json:"options,omitempty"`
+	SecretOptions []renderedSecret       `json:"secretOptions,omitempty"`
+}
+
+// renderedContainerDefinition is a container definition whose Output-valued fields have been
+// resolved, ready to be marshalled into the container definitions of a task definition.
+type renderedContainerDefinition struct {
+	TaskDefinitionContainerDefinitionInputs
+
+	LogConfiguration *renderedLogConfiguration `json:"logConfiguration,omitempty"`
+	PortMappings     []renderedPortMapping     `json:"portMappings,omitempty"`
+	Secrets          []renderedSecret          `json:"secrets,omitempty"`
+}
+
+// resolveContainerDefinitions resolves the built images, port mappings, secrets and log options of
+// the container definitions and renders them as the container definitions JSON of a task definition.
+func resolveContainerDefinitions(containerDefinitions []TaskDefinitionContainerDefinitionInputs) pulumi.StringOutput {
+	var inputs []interface{}
+	var setters []func(definitions []renderedContainerDefinition, value interface{})
+
+	for i, containerDefinition := range containerDefinitions {
+		i := i
+
+		if containerDefinition.ImageBuild != nil {
+			inputs = append(inputs, containerDefinition.builtImage)
+			setters = append(setters, func(definitions []renderedContainerDefinition, value interface{}) {
+				definitions[i].Image = value.(string)
+			})
+		}
+
+		for j, portMapping := range containerDefinition.PortMappings {
+			j := j
+
+			if portMapping.ContainerPort != nil {
+				inputs = append(inputs, portMapping.ContainerPort)
+				setters = append(setters, func(definitions []renderedContainerDefinition, value interface{}) {
+					definitions[i].PortMappings[j].ContainerPort = value.(*int)
+				})
+			}
+
+			if portMapping.HostPort != nil {
+				inputs = append(inputs, portMapping.HostPort)
+				setters = append(setters, func(definitions []renderedContainerDefinition, value interface{}) {
+					definitions[i].PortMappings[j].HostPort = value.(*int)
+				})
+			}
+		}
+
+		for j, secret := range containerDefinition.Secrets {
+			j := j
+
+			if secret.ValueFrom != nil {
+				inputs = append(inputs, secret.ValueFrom)
+				setters = append(setters, func(definitions []renderedContainerDefinition, value interface{}) {
+					definitions[i].Secrets[j].ValueFrom = value.(string)
+				})
+			}
+		}
+
+		if containerDefinition.LogConfiguration == nil {
+			continue
+		}
+
+		for j, secret := range containerDefinition.LogConfiguration.SecretOptions {
+			j := j
+
+			if secret.ValueFrom != nil {
+				inputs = append(inputs, secret.ValueFrom)
+				setters = append(setters, func(definitions []renderedContainerDefinition, value interface{}) {
+					definitions[i].LogConfiguration.SecretOptions[j].ValueFrom = value.(string)
+				})
+			}
+		}
+
+		for key, option := range logConfigurationOptions(containerDefinition.LogConfiguration.Options) {
+			key := key
+
+			if _, ok := option.(pulumi.Input); !ok {
+				continue
+			}
+
+			inputs = append(inputs, option)
+			setters = append(setters, func(definitions []renderedContainerDefinition, value interface{}) {
+				definitions[i].LogConfiguration.Options[key] = value
+			})
+		}
+	}
+
+	return pulumi.All(inputs...).ApplyT(func(values []interface{}) (string, error) {
+		var definitions []renderedContainerDefinition
+		for _, containerDefinition := range containerDefinitions {
+			definition := renderedContainerDefinition{
+				TaskDefinitionContainerDefinitionInputs: containerDefinition,
+			}
+
+			for _, portMapping := range containerDefinition.PortMappings {
+				definition.PortMappings = append(definition.PortMappings, renderedPortMapping{
+					AppProtocol: portMapping.AppProtocol,
+					Name:        portMapping.Name,
+					Protocol:    string(portMapping.Protocol),
+				})
+			}
+
+			for _, secret := range containerDefinition.Secrets {
+				definition.Secrets = append(definition.Secrets, renderedSecret{
+					Name: secret.Name,
+				})
+			}
+
+			if containerDefinition.LogConfiguration != nil {
+				definition.LogConfiguration = &renderedLogConfiguration{
+					LogDriver: containerDefinition.LogConfiguration.LogDriver,
+					Options:   logConfigurationOptions(containerDefinition.LogConfiguration.Options),
+				}
+
+				for _, secret := range containerDefinition.LogConfiguration.SecretOptions {
+					definition.LogConfiguration.SecretOptions = append(definition.LogConfiguration.SecretOptions, renderedSecret{
+						Name: secret.Name,
+					})
+				}
+			}
+
+			definitions = append(definitions, definition)
+		}
+
+		for i, value := range values {
+			setters[i](definitions, value)
+		}
+
+		return renderContainerDefinitions(definitions)
+	}).(pulumi.StringOutput)
+}
+
+// logConfigurationOptions copies the options of a log configuration into a map that can be
+// marshalled once its values are resolved.
+func logConfigurationOptions(options interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	switch options := options.(type) {
+	case map[string]pulumi.StringInput:
+		for key, value := range options {
+			result[key] = value
+		}
+	case map[string]string:
+		for key, value := range options {
+			result[key] = value
+		}
+	case map[string]interface{}:
+		for key, value := range options {
+			result[key] = value
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+// renderContainerDefinitions marshals the container definitions in a stable order, so that the
+// rendered JSON, and the task definition family derived from it, only change with the containers.
+func renderContainerDefinitions(definitions []renderedContainerDefinition) (string, error) {
+	sorted, err := sortContainerDefinitions(definitions)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(sorted)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// sortContainerDefinitions orders the container definitions by name, except that containers come
+// after the containers they depend on.
+func sortContainerDefinitions(definitions []renderedContainerDefinition) ([]renderedContainerDefinition, error) {
+	remaining := make([]renderedContainerDefinition, len(definitions))
+	copy(remaining, definitions)
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Name < remaining[j].Name
+	})
+
+	names := map[string]bool{}
+	for _, definition := range definitions {
+		names[definition.Name] = true
+	}
+
+	sorted := make([]renderedContainerDefinition, 0, len(definitions))
+	placed := map[string]bool{}
+	for len(remaining) > 0 {
+		next := -1
+		for i, definition := range remaining {
+			ready := true
+			for _, dependency := range definition.DependsOn {
+				if names[dependency.ContainerName] && !placed[dependency.ContainerName] {
+					ready = false
+				}
+			}
+
+			if ready {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			var cycle []string
+			for _, definition := range remaining {
+				cycle = append(cycle, definition.Name)
+			}
+
+			return nil, fmt.Errorf("Containers %v have circular [dependsOn] dependencies", cycle)
+		}
+
+		sorted = append(sorted, remaining[next])
+		placed[remaining[next].Name] = true
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,415 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the container definitions")
+
+type containerDefinitionMocks struct{}
+
+func (containerDefinitionMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	outputs := args.Inputs.Copy()
+	if args.TypeToken == "aws:lb/targetGroup:TargetGroup" {
+		outputs["name"] = resource.NewStringProperty(args.Name)
+		outputs["arn"] = resource.NewStringProperty("arn:aws:elasticloadbalancing:us-west-2:123456789012:targetgroup/" + args.Name + "/0123456789abcdef")
+	}
+
+	if args.TypeToken == "aws:secretsmanager/secret:Secret" {
+		outputs["arn"] = resource.NewStringProperty("arn:aws:secretsmanager:us-west-2:123456789012:secret:" + args.Name + "-AbCdEf")
+	}
+
+	return args.Name + "-id", outputs, nil
+}
+
+func (containerDefinitionMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	switch args.Token {
+	case "aws:index/getPartition:getPartition":
+		return resource.NewPropertyMapFromMap(map[string]interface{}{"partition": "aws"}), nil
+	case "aws:index/getRegion:getRegion":
+		return resource.NewPropertyMapFromMap(map[string]interface{}{"name": "us-west-2"}), nil
+	case "aws:index/getCallerIdentity:getCallerIdentity":
+		return resource.NewPropertyMapFromMap(map[string]interface{}{"accountId": "123456789012"}), nil
+	case "aws:iam/getPolicyDocument:getPolicyDocument":
+		policy, err := json.Marshal(args.Args.Mappable())
+		if err != nil {
+			return nil, err
+		}
+
+		return resource.NewPropertyMapFromMap(map[string]interface{}{"json": string(policy)}), nil
+	}
+
+	return args.Args, nil
+}
+
+// renderContainers computes and resolves the container definitions built by the program, the way a
+// task definition does.
+func renderContainers(t *testing.T, program func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error)) string {
+	var rendered string
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		containers, err := program(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = taskDefinitionSecrets(ctx, "task", containers)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		resolveContainerDefinitions(computeContainerDefinitions(nil, containers, nil)).ApplyT(func(containerDefJSON string) string {
+			defer wg.Done()
+			rendered = containerDefJSON
+			return containerDefJSON
+		})
+		wg.Wait()
+
+		return nil
+	}, pulumi.WithMocks("project", "stack", containerDefinitionMocks{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rendered
+}
+
+func assertGolden(t *testing.T, name, rendered string) {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(rendered), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	indented.WriteString("\n")
+
+	path := filepath.Join("testdata", "container_definitions", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, indented.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file, run the tests with -update to create it: %v", err)
+	}
+
+	if !bytes.Equal(expected, indented.Bytes()) {
+		t.Errorf("container definitions do not match %s:\n%s", path, indented.String())
+	}
+}
+
+func TestContainerDefinitions(t *testing.T) {
+	essential := false
+
+	cases := map[string]func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error){
+		"single": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			return map[string]TaskDefinitionContainerDefinitionInputs{
+				"app": {
+					Image:  "nginx:latest",
+					CPU:    256,
+					Memory: 512,
+					Environment: []TaskDefinitionKeyValuePairInputs{
+						{Name: "PORT", Value: "8080"},
+					},
+				},
+			}, nil
+		},
+		"sorted-by-name": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			return map[string]TaskDefinitionContainerDefinitionInputs{
+				"worker":  {Image: "worker:1"},
+				"api":     {Image: "api:1"},
+				"metrics": {Image: "metrics:1", Essential: &essential},
+				"cache":   {Image: "redis:7"},
+			}, nil
+		},
+		"depends-on": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			return map[string]TaskDefinitionContainerDefinitionInputs{
+				"app": {
+					Image: "app:1",
+					DependsOn: []TaskDefinitionContainerDependencyInputs{
+						{ContainerName: "migrate", Condition: "SUCCESS"},
+						{ContainerName: "log-router", Condition: "START"},
+					},
+				},
+				"migrate": {
+					Image: "app:1",
+					DependsOn: []TaskDefinitionContainerDependencyInputs{
+						{ContainerName: "log-router", Condition: "START"},
+					},
+				},
+				"log-router": {Image: "fluent-bit:latest"},
+			}, nil
+		},
+		"outputs": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			targetGroup, err := lb.NewTargetGroup(ctx, "web", &lb.TargetGroupArgs{
+				Port:     pulumi.IntPtr(8080),
+				Protocol: pulumi.StringPtr("HTTP"),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]TaskDefinitionContainerDefinitionInputs{
+				"web": {
+					Image: "web:1",
+					LogConfiguration: &TaskDefinitionLogConfigurationInputs{
+						LogDriver: "awslogs",
+						Options: map[string]pulumi.StringInput{
+							"awslogs-group":         targetGroup.Name,
+							"awslogs-region":        pulumi.String("us-west-2"),
+							"awslogs-stream-prefix": pulumi.String("web"),
+						},
+					},
+					PortMappings: []TaskDefinitionPortMappingInputs{
+						{TargetGroup: targetGroup},
+					},
+				},
+			}, nil
+		},
+		"secrets": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			return map[string]TaskDefinitionContainerDefinitionInputs{
+				"app": {
+					Image: "app:1",
+					Secrets: []TaskDefinitionSecretInputs{
+						{Name: "API_TOKEN", Value: pulumi.ToSecret(pulumi.String("s3cr3t")).(pulumi.StringOutput)},
+						{Name: "DB_PASSWORD", ValueFrom: pulumi.String("arn:aws:secretsmanager:us-west-2:123456789012:secret:db-AbCdEf"), JSONKey: "password", VersionStage: "AWSCURRENT"},
+						{Name: "FEATURE_FLAGS", ValueFrom: pulumi.String("/app/feature-flags")},
+					},
+					LogConfiguration: &TaskDefinitionLogConfigurationInputs{
+						LogDriver: "splunk",
+						SecretOptions: []TaskDefinitionSecretInputs{
+							{Name: "splunk-token", ValueFrom: pulumi.String("arn:aws:ssm:us-west-2:123456789012:parameter/splunk-token")},
+						},
+					},
+				},
+			}, nil
+		},
+		"log-router": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			containers := map[string]TaskDefinitionContainerDefinitionInputs{
+				"app": {Image: "app:1"},
+				"debug": {
+					Image: "debug:1",
+					LogConfiguration: &TaskDefinitionLogConfigurationInputs{
+						LogDriver: "json-file",
+					},
+				},
+			}
+
+			_, err := taskDefinitionLogRouter(ctx, &TaskDefinitionLogRouterInputs{
+				CloudWatch: &TaskDefinitionLogRouterCloudWatchInputs{
+					LogGroupName: "app-logs",
+				},
+			}, containers, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			return containers, nil
+		},
+		"observability": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			containers := map[string]TaskDefinitionContainerDefinitionInputs{
+				"api": {
+					Image: "api:1",
+					Environment: []TaskDefinitionKeyValuePairInputs{
+						{Name: "OTEL_SERVICE_NAME", Value: "checkout"},
+					},
+				},
+				// FireLens log routers are not instrumented.
+				"log-router": {
+					Image: "fluent-bit:1",
+					FirelensConfiguration: &TaskDefinitionFirelensConfigurationInputs{
+						Type: "fluentbit",
+					},
+				},
+			}
+
+			_, err := taskDefinitionObservability(ctx, &TaskDefinitionObservabilityInputs{
+				Metrics: true,
+				Prometheus: &TaskDefinitionObservabilityPrometheusInputs{
+					Port: 9100,
+				},
+				Traces: true,
+			}, containers)
+			if err != nil {
+				return nil, err
+			}
+
+			return containers, nil
+		},
+		"port-mappings": func(ctx *pulumi.Context) (map[string]TaskDefinitionContainerDefinitionInputs, error) {
+			return map[string]TaskDefinitionContainerDefinitionInputs{
+				"api": {
+					Image: "api:1",
+					PortMappings: []TaskDefinitionPortMappingInputs{
+						{ContainerPort: pulumi.IntPtr(8080)},
+						{ContainerPort: pulumi.IntPtr(9090), Name: "grpc", AppProtocol: "grpc", Protocol: "tcp"},
+					},
+				},
+				"sidecar": {Image: "sidecar:1"},
+			}, nil
+		},
+	}
+
+	for name, program := range cases {
+		t.Run(name, func(t *testing.T) {
+			rendered := renderContainers(t, program)
+
+			assertGolden(t, name, rendered)
+
+			// Rendering the same containers again must produce the same JSON.
+			for i := 0; i < 10; i++ {
+				if again := renderContainers(t, program); again != rendered {
+					t.Fatalf("container definitions are not deterministic:\n%s\n%s", rendered, again)
+				}
+			}
+		})
+	}
+}
+
+func TestContainerDefinitionsCircularDependencies(t *testing.T) {
+	_, err := sortContainerDefinitions([]renderedContainerDefinition{
+		{
+			TaskDefinitionContainerDefinitionInputs: TaskDefinitionContainerDefinitionInputs{
+				Name:      "a",
+				DependsOn: []TaskDefinitionContainerDependencyInputs{{ContainerName: "b"}},
+			},
+		},
+		{
+			TaskDefinitionContainerDefinitionInputs: TaskDefinitionContainerDefinitionInputs{
+				Name:      "b",
+				DependsOn: []TaskDefinitionContainerDependencyInputs{{ContainerName: "a"}},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected circular dependencies to be rejected")
+	}
+}
+
+func TestLintContainerDefinitions(t *testing.T) {
+	essential := false
+
+	err := lintContainerDefinitions(map[string]TaskDefinitionContainerDefinitionInputs{
+		"a": {
+			Essential:    &essential,
+			DependsOn:    []TaskDefinitionContainerDependencyInputs{{ContainerName: "b", Condition: "START"}},
+			Memory:       4096,
+			PortMappings: []TaskDefinitionPortMappingInputs{{ContainerPort: pulumi.IntPtr(8080), HostPort: pulumi.IntPtr(80)}},
+			Privileged:   true,
+		},
+		"b": {
+			Essential:    &essential,
+			DependsOn:    []TaskDefinitionContainerDependencyInputs{{ContainerName: "a", Condition: "HEALTHY"}},
+			HealthCheck:  &TaskDefinitionHealthCheckInputs{Command: []string{"curl"}, Interval: 1},
+			PortMappings: []TaskDefinitionPortMappingInputs{{ContainerPort: pulumi.IntPtr(80)}},
+			Ulimits:      []TaskDefinitionUlimitInputs{{Name: "files"}},
+		},
+	}, taskDefinitionLint{Fargate: true, Memory: "2 GB", NetworkMode: "awsvpc"})
+	if err == nil {
+		t.Fatal("expected the container definitions to be rejected")
+	}
+
+	for _, problem := range []string{
+		"At least one container must be [essential]",
+		"Container b: [dependsOn] waits for container a to be HEALTHY, which has no [healthCheck]",
+		"Containers [a b] have circular [dependsOn] dependencies",
+		"Container a: [hostPort] 80 must equal [containerPort] 8080 on Fargate",
+		"Container b: host port 80/tcp is also used by container a",
+		"Container a: [memory] 4096 MiB exceeds the 2048 MiB of the task",
+		"Container a: [privileged] is not supported on Fargate",
+		"Container b: [healthCheck] [command] must start with one of [CMD CMD-SHELL NONE]",
+		"Container b: [healthCheck] [interval] must be between 5 and 300, got 1",
+		`Container b: ulimit "files" is not one of`,
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected %q to be reported in:\n%s", problem, err)
+		}
+	}
+}
+
+func TestLintContainerDefinitionsSkipsUnknownPorts(t *testing.T) {
+	err := lintContainerDefinitions(map[string]TaskDefinitionContainerDefinitionInputs{
+		"a": {PortMappings: []TaskDefinitionPortMappingInputs{{ContainerPort: pulumi.Int(80).ToIntPtrOutput()}}},
+		"b": {PortMappings: []TaskDefinitionPortMappingInputs{{ContainerPort: pulumi.Int(80).ToIntPtrOutput()}}},
+	}, taskDefinitionLint{Fargate: true, NetworkMode: "awsvpc"})
+	if err != nil {
+		t.Fatalf("expected ports given as outputs to be skipped, got %v", err)
+	}
+}
+
+func TestLoadBalancers(t *testing.T) {
+	var loadBalancers []ecs.ServiceLoadBalancer
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		targetGroup, err := lb.NewTargetGroup(ctx, "web", &lb.TargetGroupArgs{
+			Port: pulumi.IntPtr(80),
+		})
+		if err != nil {
+			return err
+		}
+
+		containers := map[string]TaskDefinitionContainerDefinitionInputs{
+			"web": {
+				Image: "web:1",
+				PortMappings: []TaskDefinitionPortMappingInputs{
+					{TargetGroup: targetGroup},
+					{ContainerPort: pulumi.IntPtr(9000)},
+				},
+			},
+			"admin": {
+				Image: "admin:1",
+				PortMappings: []TaskDefinitionPortMappingInputs{
+					{
+						ContainerPort:  pulumi.IntPtr(8081),
+						TargetGroupArn: pulumi.String("arn:aws:elasticloadbalancing:us-west-2:123456789012:targetgroup/admin/0123456789abcdef"),
+					},
+				},
+			},
+			"sidecar": {Image: "sidecar:1"},
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		computeLoadBalancers(computeContainerDefinitions(nil, containers, nil)).ApplyT(func(result []ecs.ServiceLoadBalancer) []ecs.ServiceLoadBalancer {
+			defer wg.Done()
+			loadBalancers = result
+			return result
+		})
+		wg.Wait()
+
+		return nil
+	}, pulumi.WithMocks("project", "stack", containerDefinitionMocks{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loadBalancers) != 2 {
+		t.Fatalf("expected 2 load balancers, got %d", len(loadBalancers))
+	}
+
+	if loadBalancers[0].ContainerName != "admin" || loadBalancers[0].ContainerPort != 8081 {
+		t.Errorf("unexpected load balancer %+v", loadBalancers[0])
+	}
+
+	if loadBalancers[1].ContainerName != "web" || loadBalancers[1].ContainerPort != 80 || *loadBalancers[1].TargetGroupArn != "arn:aws:elasticloadbalancing:us-west-2:123456789012:targetgroup/web/0123456789abcdef" {
+		t.Errorf("unexpected load balancer %+v", loadBalancers[1])
+	}
+}
@@ -0,0 +1,159 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecr"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type TaskDefinitionContainerImageBuildInputs struct {
+	Args          map[string]string `pulumi:"args"`
+	CacheFrom     []string          `pulumi:"cacheFrom"`
+	DockerFile    string            `pulumi:"dockerfile"`
+	Path          string            `pulumi:"path"`
+	RepositoryURL string            `pulumi:"repositoryUrl"`
+	Target        string            `pulumi:"target"`
+}
+
+type taskDefinitionImagesResult struct {
+	Repository     *ecr.Repository
+	RepositoryURLs []pulumi.StringOutput
+}
+
+// taskDefinitionImages builds and pushes the image of every container given an [imageBuild], to the
+// repository it names or to a repository created for the task definition, and points the container at
+// the pushed image.
+func taskDefinitionImages(ctx *pulumi.Context, name string, containers map[string]TaskDefinitionContainerDefinitionInputs, parent pulumi.Resource, opts ...pulumi.ResourceOption) (*taskDefinitionImagesResult, error) {
+	result := &taskDefinitionImagesResult{}
+
+	var containerNames []string
+	for containerName := range containers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	for _, containerName := range containerNames {
+		container := containers[containerName]
+
+		if container.Image != "" && container.ImageBuild != nil {
+			return nil, fmt.Errorf("Container %s: only one of [image] or [imageBuild] can be specified", containerName)
+		}
+
+		if container.Image == "" && container.ImageBuild == nil {
+			return nil, fmt.Errorf("Container %s: one of [image] or [imageBuild] must be specified", containerName)
+		}
+
+		if container.ImageBuild == nil {
+			continue
+		}
+
+		build := container.ImageBuild
+
+		var repositoryURL pulumi.StringOutput
+		if build.RepositoryURL != "" {
+			repositoryURL = pulumi.String(build.RepositoryURL).ToStringOutput()
+			result.RepositoryURLs = append(result.RepositoryURLs, repositoryURL)
+		} else {
+			// Containers built without a repository share one created for the task definition.
+			if result.Repository == nil {
+				repository, err := ecr.NewRepository(ctx, strings.ToLower(name), &ecr.RepositoryArgs{}, opts...)
+				if err != nil {
+					return nil, err
+				}
+
+				result.Repository = repository
+				result.RepositoryURLs = append(result.RepositoryURLs, repository.RepositoryUrl)
+			}
+
+			repositoryURL = result.Repository.RepositoryUrl
+		}
+
+		imageURI, err := computeImageFromAsset(ctx, fmt.Sprintf("%s-%s", name, containerName), &ImageArgs{
+			Args:       build.Args,
+			CacheFrom:  build.CacheFrom,
+			DockerFile: build.DockerFile,
+			Path:       build.Path,
+			Target:     build.Target,
+		}, repositoryURL, parent)
+		if err != nil {
+			return nil, err
+		}
+
+		container.builtImage = imageURI
+		containers[containerName] = container
+	}
+
+	return result, nil
+}
+
+// ecrRepositoryARN converts the URL of an ECR repository, optionally tagged or pinned to a digest, to
+// the ARN of the repository.
+func ecrRepositoryARN(partition, repositoryURL string) (string, error) {
+	parts := strings.SplitN(repositoryURL, "/", 2)
+	host := strings.Split(parts[0], ".")
+	if len(parts) != 2 || len(host) < 6 || host[1] != "dkr" || host[2] != "ecr" {
+		return "", fmt.Errorf("%s is not the URL of an ECR repository", repositoryURL)
+	}
+
+	repositoryName := parts[1]
+	if i := strings.Index(repositoryName, "@"); i >= 0 {
+		repositoryName = repositoryName[:i]
+	}
+
+	if i := strings.LastIndex(repositoryName, ":"); i >= 0 {
+		repositoryName = repositoryName[:i]
+	}
+
+	return fmt.Sprintf("arn:%s:ecr:%s:%s:repository/%s", partition, host[3], host[0], repositoryName), nil
+}
+
+// executionRoleImagesPolicy grants the execution role pull access to the repositories the images of
+// the containers are pushed to.
+func executionRoleImagesPolicy(ctx *pulumi.Context, name string, images *taskDefinitionImagesResult, executionRole *defaultRoleWithPoliciesResult, opts ...pulumi.ResourceOption) (*iam.RolePolicy, error) {
+	if len(images.RepositoryURLs) == 0 || executionRole == nil || executionRole.Role == nil {
+		return nil, nil
+	}
+
+	var repositoryURLs []interface{}
+	for _, repositoryURL := range images.RepositoryURLs {
+		repositoryURLs = append(repositoryURLs, repositoryURL)
+	}
+
+	policy := pulumi.All(repositoryURLs...).ApplyT(func(values []interface{}) (string, error) {
+		partition, err := aws.GetPartition(ctx, nil, nil)
+		if err != nil {
+			return "", err
+		}
+
+		var repositoryARNs []string
+		for _, value := range values {
+			repositoryARN, err := ecrRepositoryARN(partition.Partition, value.(string))
+			if err != nil {
+				return "", err
+			}
+
+			repositoryARNs = appendUnique(repositoryARNs, repositoryARN)
+		}
+
+		return allowPolicyDocument(ctx, []iam.GetPolicyDocumentStatement{
+			{
+				Actions:   []string{"ecr:GetAuthorizationToken"},
+				Resources: []string{"*"},
+			},
+			{
+				Actions:   []string{"ecr:BatchCheckLayerAvailability", "ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer"},
+				Resources: repositoryARNs,
+			},
+		})
+	}).(pulumi.StringOutput)
+
+	return iam.NewRolePolicy(ctx, fmt.Sprintf("%s-images", name), &iam.RolePolicyArgs{
+		Role:   executionRole.Role.Name,
+		Policy: policy,
+	}, append(opts, pulumi.Parent(executionRole.Role))...)
+}
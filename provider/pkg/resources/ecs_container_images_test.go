@@ -0,0 +1,38 @@
+package resources
+
+import "testing"
+
+func TestECRRepositoryARN(t *testing.T) {
+	cases := map[string]string{
+		"123456789012.dkr.ecr.us-west-2.amazonaws.com/app":                     "arn:aws:ecr:us-west-2:123456789012:repository/app",
+		"123456789012.dkr.ecr.us-west-2.amazonaws.com/team/app:1.0":            "arn:aws:ecr:us-west-2:123456789012:repository/team/app",
+		"123456789012.dkr.ecr.us-west-2.amazonaws.com/app@sha256:0123456789ab": "arn:aws:ecr:us-west-2:123456789012:repository/app",
+	}
+
+	for repositoryURL, expected := range cases {
+		arn, err := ecrRepositoryARN("aws", repositoryURL)
+		if err != nil {
+			t.Errorf("parsing %s: %v", repositoryURL, err)
+			continue
+		}
+
+		if arn != expected {
+			t.Errorf("expected %s to be repository %s, got %s", repositoryURL, expected, arn)
+		}
+	}
+
+	arn, err := ecrRepositoryARN("aws-cn", "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "arn:aws-cn:ecr:cn-north-1:123456789012:repository/app"; arn != expected {
+		t.Errorf("expected repository %s, got %s", expected, arn)
+	}
+
+	for _, repositoryURL := range []string{"nginx:latest", "docker.io/library/nginx", "public.ecr.aws/nginx/nginx:latest"} {
+		if _, err := ecrRepositoryARN("aws", repositoryURL); err == nil {
+			t.Errorf("expected %s not to be the URL of an ECR repository", repositoryURL)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestResolveContainers(t *testing.T) {
+	container := &TaskDefinitionContainerDefinitionInputs{Image: "nginx:latest"}
+
+	containers, err := resolveContainers("web", container, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(containers) != 1 || containers["web"].Image != "nginx:latest" {
+		t.Errorf("expected a single container named after the task definition, got %v", containers)
+	}
+
+	containers, err = resolveContainers("web", nil, map[string]TaskDefinitionContainerDefinitionInputs{
+		"app":     {Image: "app:1"},
+		"sidecar": {Image: "sidecar:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := containers["web"]; ok || len(containers) != 2 {
+		t.Errorf("expected the containers to keep their names, got %v", containers)
+	}
+
+	cases := map[string]struct {
+		container  *TaskDefinitionContainerDefinitionInputs
+		containers map[string]TaskDefinitionContainerDefinitionInputs
+		problem    string
+	}{
+		"container and containers": {
+			container:  container,
+			containers: map[string]TaskDefinitionContainerDefinitionInputs{"app": {Image: "app:1"}},
+			problem:    "Only one of `container` or `containers` can be provided.",
+		},
+		"neither": {
+			problem: "Either `container` or `containers` must be provided.",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := resolveContainers("web", c.container, c.containers)
+			if err == nil || !strings.Contains(err.Error(), c.problem) {
+				t.Errorf("expected an error containing %q, got %v", c.problem, err)
+			}
+		})
+	}
+}
+
+func TestValidatePortMappings(t *testing.T) {
+	targetGroup := &lb.TargetGroup{}
+	targetGroupARN := pulumi.String("arn:aws:elasticloadbalancing:us-west-2:123456789012:targetgroup/web/0123456789abcdef")
+
+	valid := []TaskDefinitionPortMappingInputs{
+		{TargetGroup: targetGroup},
+		{ContainerPort: pulumi.IntPtr(8080), TargetGroupArn: targetGroupARN},
+		{HostPort: pulumi.IntPtr(9000)},
+		{AppProtocol: "grpc", ContainerPort: pulumi.IntPtr(50051), Name: "grpc"},
+		{ContainerPort: pulumi.IntPtr(9901), Name: "admin"},
+	}
+
+	err := validatePortMappings(map[string]TaskDefinitionContainerDefinitionInputs{
+		"app":     {PortMappings: valid},
+		"sidecar": {PortMappings: []TaskDefinitionPortMappingInputs{{ContainerPort: pulumi.IntPtr(9901), Name: "admin"}}},
+	})
+	if err != nil {
+		t.Errorf("expected the port mappings to be valid, got %v", err)
+	}
+
+	cases := map[string]struct {
+		mappings []TaskDefinitionPortMappingInputs
+		problem  string
+	}{
+		"target group and target group ARN": {
+			mappings: []TaskDefinitionPortMappingInputs{{TargetGroup: targetGroup, TargetGroupArn: targetGroupARN}},
+			problem:  "only one of [targetGroup] or [targetGroupArn]",
+		},
+		"without a port": {
+			mappings: []TaskDefinitionPortMappingInputs{{Name: "http"}},
+			problem:  "port mappings without a [targetGroup] require a [containerPort]",
+		},
+		"target group ARN without a port": {
+			mappings: []TaskDefinitionPortMappingInputs{{HostPort: pulumi.IntPtr(80), TargetGroupArn: targetGroupARN}},
+			problem:  "port mappings with a [targetGroupArn] require a [containerPort]",
+		},
+		"app protocol without a name": {
+			mappings: []TaskDefinitionPortMappingInputs{{AppProtocol: "http", ContainerPort: pulumi.IntPtr(80)}},
+			problem:  "port mappings with an [appProtocol] require a [name]",
+		},
+		"duplicate names": {
+			mappings: []TaskDefinitionPortMappingInputs{
+				{ContainerPort: pulumi.IntPtr(80), Name: "http"},
+				{ContainerPort: pulumi.IntPtr(8080), Name: "http"},
+			},
+			problem: "port mapping name http is used more than once",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validatePortMappings(map[string]TaskDefinitionContainerDefinitionInputs{
+				"app": {PortMappings: c.mappings},
+			})
+			if err == nil || !strings.Contains(err.Error(), "Container app: "+c.problem) {
+				t.Errorf("expected an error containing %q, got %v", c.problem, err)
+			}
+		})
+	}
+}
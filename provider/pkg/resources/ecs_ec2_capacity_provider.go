@@ -0,0 +1,384 @@
+package resources
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/autoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const EC2CapacityProviderIdentifier = "awsx-go:ecs:Ec2CapacityProvider"
+
+const (
+	ec2CapacityProviderAMITypeX86 = "x86_64"
+	ec2CapacityProviderAMITypeARM = "arm64"
+	ec2CapacityProviderAMITypeGPU = "gpu"
+)
+
+type EC2CapacityProviderManagedScalingInputs struct {
+	InstanceWarmupPeriod   int  `pulumi:"instanceWarmupPeriod"`
+	MaximumScalingStepSize int  `pulumi:"maximumScalingStepSize"`
+	MinimumScalingStepSize int  `pulumi:"minimumScalingStepSize"`
+	Skip                   bool `pulumi:"skip"`
+	TargetCapacity         int  `pulumi:"targetCapacity"`
+}
+
+type EC2CapacityProviderArgs struct {
+	AMI                          string                                  `pulumi:"ami"`
+	AMIType                      string                                  `pulumi:"amiType"`
+	AssociatePublicIPAddress     bool                                    `pulumi:"associatePublicIpAddress"`
+	ClusterName                  pulumi.StringInput                      `pulumi:"clusterName"`
+	InstanceRole                 DefaultRoleWithPolicyInputs             `pulumi:"instanceRole"`
+	InstanceType                 string                                  `pulumi:"instanceType"`
+	KeyName                      string                                  `pulumi:"keyName"`
+	ManagedScaling               EC2CapacityProviderManagedScalingInputs `pulumi:"managedScaling"`
+	ManagedTerminationProtection string                                  `pulumi:"managedTerminationProtection"`
+	MaxSize                      int                                     `pulumi:"maxSize"`
+	MinSize                      int                                     `pulumi:"minSize"`
+	Name                         string                                  `pulumi:"name"`
+	RootVolumeSize               int                                     `pulumi:"rootVolumeSize"`
+	SecurityGroupIDs             []string                                `pulumi:"securityGroupIds"`
+	SubnetIDs                    pulumi.StringArrayInput                 `pulumi:"subnetIds"`
+	Tags                         map[string]string                       `pulumi:"tags"`
+	UserData                     string                                  `pulumi:"userData"`
+	VpcID                        pulumi.StringInput                      `pulumi:"vpcId"`
+}
+
+type EC2CapacityProvider struct {
+	pulumi.ResourceState
+
+	AutoScalingGroup     *autoscaling.Group    `pulumi:"autoScalingGroup"`
+	CapacityProvider     *ecs.CapacityProvider `pulumi:"capacityProvider"`
+	CapacityProviderName pulumi.StringOutput   `pulumi:"capacityProviderName"`
+	InstanceProfile      *iam.InstanceProfile  `pulumi:"instanceProfile"`
+	LaunchTemplate       *ec2.LaunchTemplate   `pulumi:"launchTemplate"`
+	Role                 *iam.Role             `pulumi:"role"`
+	SecurityGroup        *ec2.SecurityGroup    `pulumi:"securityGroup"`
+}
+
+func NewEC2CapacityProvider(ctx *pulumi.Context, name string, args *EC2CapacityProviderArgs, opts ...pulumi.ResourceOption) (*EC2CapacityProvider, error) {
+	if args == nil {
+		args = &EC2CapacityProviderArgs{}
+	}
+
+	component := &EC2CapacityProvider{}
+	err := ctx.RegisterComponentResource(EC2CapacityProviderIdentifier, name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, pulumi.Parent(component))
+
+	if args.ClusterName == nil {
+		return nil, fmt.Errorf("[clusterName] must be specified")
+	}
+
+	amiType := strings.ToLower(args.AMIType)
+	if amiType == "" {
+		amiType = ec2CapacityProviderAMITypeX86
+	}
+
+	switch amiType {
+	case ec2CapacityProviderAMITypeX86, ec2CapacityProviderAMITypeARM, ec2CapacityProviderAMITypeGPU:
+	default:
+		return nil, fmt.Errorf("Unknown AMI type %s. Must be one of [x86_64], [arm64] or [gpu]", args.AMIType)
+	}
+
+	managedTerminationProtection := strings.ToUpper(args.ManagedTerminationProtection)
+	if managedTerminationProtection == "" {
+		managedTerminationProtection = "ENABLED"
+		if args.ManagedScaling.Skip {
+			managedTerminationProtection = "DISABLED"
+		}
+	}
+
+	if managedTerminationProtection != "ENABLED" && managedTerminationProtection != "DISABLED" {
+		return nil, fmt.Errorf("Unknown managed termination protection setting %s", args.ManagedTerminationProtection)
+	}
+
+	if managedTerminationProtection == "ENABLED" && args.ManagedScaling.Skip {
+		return nil, fmt.Errorf("Managed termination protection requires managed scaling")
+	}
+
+	maxSize := args.MaxSize
+	if maxSize == 0 {
+		maxSize = 10
+	}
+
+	if args.MinSize > maxSize {
+		return nil, fmt.Errorf("[minSize] can not be greater than [maxSize]")
+	}
+
+	ami := args.AMI
+	if ami == "" {
+		ami, err = ecsOptimizedAMI(ctx, amiType, pulumi.Parent(component))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	instanceType := args.InstanceType
+	if instanceType == "" {
+		instanceType = defaultEC2CapacityProviderInstanceType(amiType)
+	}
+
+	// The default VPC has no NAT, so instances need a public IP to reach the ECS endpoints.
+	network, err := instanceNetwork(ctx, name, &instanceNetworkInputs{
+		AssociatePublicIPAddress: args.AssociatePublicIPAddress,
+		SecurityGroupIDs:         args.SecurityGroupIDs,
+		SubnetIDs:                args.SubnetIDs,
+		Tags:                     args.Tags,
+		VpcID:                    args.VpcID,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.SecurityGroup = network.SecurityGroup
+
+	if args.InstanceRole.Skip {
+		return nil, fmt.Errorf("[instanceRole] cannot be skipped, container instances require a role to join the cluster")
+	}
+
+	if args.InstanceRole.RoleARN == "" && args.InstanceRole.Args == nil {
+		args.InstanceRole.Args = &RoleWithPolicyInputs{}
+	}
+
+	if args.InstanceRole.Args != nil && len(args.InstanceRole.Args.PolicyARNs) == 0 {
+		args.InstanceRole.Args.PolicyARNs = defaultContainerInstanceRolePolicyARNs()
+	}
+
+	assumeRolePolicy, err := serviceAssumeRolePolicy(ctx, "ec2.amazonaws.com")
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := defaultRoleWithPolicies(ctx, name, args.InstanceRole, assumeRolePolicy.Json, opts...)
+	if err != nil {
+		return nil, err
+	}
+	component.Role = role.Role
+
+	instanceProfile, err := iam.NewInstanceProfile(ctx, name, &iam.InstanceProfileArgs{
+		Role: role.RoleName(),
+		Tags: pulumi.ToStringMap(args.Tags),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	component.InstanceProfile = instanceProfile
+
+	userData := args.ClusterName.ToStringOutput().ApplyT(func(clusterName string) string {
+		return base64.StdEncoding.EncodeToString([]byte(ecsContainerInstanceUserData(clusterName, amiType, args.UserData)))
+	}).(pulumi.StringOutput)
+
+	var keyName pulumi.StringPtrInput
+	if args.KeyName != "" {
+		keyName = pulumi.StringPtr(args.KeyName)
+	}
+
+	launchTemplateArgs := &ec2.LaunchTemplateArgs{
+		ImageId:      pulumi.StringPtr(ami),
+		InstanceType: pulumi.StringPtr(instanceType),
+		KeyName:      keyName,
+		IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileArgs{
+			Arn: instanceProfile.Arn,
+		},
+		MetadataOptions: &ec2.LaunchTemplateMetadataOptionsArgs{
+			HttpEndpoint: pulumi.StringPtr("enabled"),
+			HttpTokens:   pulumi.StringPtr("required"),
+			// Containers in bridge mode are one hop further away from the instance metadata service.
+			HttpPutResponseHopLimit: pulumi.IntPtr(2),
+		},
+		NetworkInterfaces: ec2.LaunchTemplateNetworkInterfaceArray{
+			&ec2.LaunchTemplateNetworkInterfaceArgs{
+				AssociatePublicIpAddress: pulumi.StringPtr(fmt.Sprintf("%v", network.AssociatePublicIPAddress)),
+				SecurityGroups:           network.SecurityGroupIDs,
+			},
+		},
+		TagSpecifications: ec2.LaunchTemplateTagSpecificationArray{
+			&ec2.LaunchTemplateTagSpecificationArgs{
+				ResourceType: pulumi.StringPtr("instance"),
+				Tags:         pulumi.ToStringMap(ec2CapacityProviderInstanceTags(name, args.Tags)),
+			},
+		},
+		Tags:                 pulumi.ToStringMap(args.Tags),
+		UpdateDefaultVersion: pulumi.BoolPtr(true),
+		UserData:             userData,
+	}
+
+	if args.RootVolumeSize > 0 {
+		launchTemplateArgs.BlockDeviceMappings = ec2.LaunchTemplateBlockDeviceMappingArray{
+			&ec2.LaunchTemplateBlockDeviceMappingArgs{
+				DeviceName: pulumi.StringPtr("/dev/xvda"),
+				Ebs: &ec2.LaunchTemplateBlockDeviceMappingEbsArgs{
+					Encrypted:  pulumi.StringPtr("true"),
+					VolumeSize: pulumi.IntPtr(args.RootVolumeSize),
+					VolumeType: pulumi.StringPtr("gp3"),
+				},
+			},
+		}
+	}
+
+	launchTemplate, err := ec2.NewLaunchTemplate(ctx, name, launchTemplateArgs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	component.LaunchTemplate = launchTemplate
+
+	// ECS manages the desired capacity of the group through the capacity provider, and expects the
+	// group to be tagged so that it can tell it is managed.
+	groupTags := autoscaling.GroupTagArray{
+		&autoscaling.GroupTagArgs{
+			Key:               pulumi.String("AmazonECSManaged"),
+			Value:             pulumi.String("true"),
+			PropagateAtLaunch: pulumi.Bool(true),
+		},
+	}
+
+	group, err := autoscaling.NewGroup(ctx, name, &autoscaling.GroupArgs{
+		LaunchTemplate: &autoscaling.GroupLaunchTemplateArgs{
+			Id:      launchTemplate.ID(),
+			Version: pulumi.String("$Latest"),
+		},
+		MaxSize:            pulumi.Int(maxSize),
+		MinSize:            pulumi.Int(args.MinSize),
+		ProtectFromScaleIn: pulumi.BoolPtr(managedTerminationProtection == "ENABLED"),
+		Tags:               groupTags,
+		VpcZoneIdentifiers: network.SubnetIDs,
+	}, append(opts, pulumi.IgnoreChanges([]string{"desiredCapacity"}))...)
+	if err != nil {
+		return nil, err
+	}
+	component.AutoScalingGroup = group
+
+	managedScaling := &ecs.CapacityProviderAutoScalingGroupProviderManagedScalingArgs{
+		Status: pulumi.StringPtr("DISABLED"),
+	}
+
+	if !args.ManagedScaling.Skip {
+		targetCapacity := args.ManagedScaling.TargetCapacity
+		if targetCapacity == 0 {
+			targetCapacity = 100
+		}
+
+		managedScaling = &ecs.CapacityProviderAutoScalingGroupProviderManagedScalingArgs{
+			Status:         pulumi.StringPtr("ENABLED"),
+			TargetCapacity: pulumi.IntPtr(targetCapacity),
+		}
+
+		if args.ManagedScaling.InstanceWarmupPeriod > 0 {
+			managedScaling.InstanceWarmupPeriod = pulumi.IntPtr(args.ManagedScaling.InstanceWarmupPeriod)
+		}
+
+		if args.ManagedScaling.MaximumScalingStepSize > 0 {
+			managedScaling.MaximumScalingStepSize = pulumi.IntPtr(args.ManagedScaling.MaximumScalingStepSize)
+		}
+
+		if args.ManagedScaling.MinimumScalingStepSize > 0 {
+			managedScaling.MinimumScalingStepSize = pulumi.IntPtr(args.ManagedScaling.MinimumScalingStepSize)
+		}
+	}
+
+	var capacityProviderName pulumi.StringPtrInput
+	if args.Name != "" {
+		capacityProviderName = pulumi.StringPtr(args.Name)
+	}
+
+	capacityProvider, err := ecs.NewCapacityProvider(ctx, name, &ecs.CapacityProviderArgs{
+		Name: capacityProviderName,
+		AutoScalingGroupProvider: &ecs.CapacityProviderAutoScalingGroupProviderArgs{
+			AutoScalingGroupArn:          group.Arn,
+			ManagedScaling:               managedScaling,
+			ManagedTerminationProtection: pulumi.StringPtr(managedTerminationProtection),
+		},
+		Tags: pulumi.ToStringMap(args.Tags),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.CapacityProvider = capacityProvider
+	component.CapacityProviderName = capacityProvider.Name
+
+	return component, nil
+}
+
+func defaultContainerInstanceRolePolicyARNs() []string {
+	return []string{
+		"arn:aws:iam::aws:policy/service-role/AmazonEC2ContainerServiceforEC2Role",
+		"arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore",
+	}
+}
+
+func defaultEC2CapacityProviderInstanceType(amiType string) string {
+	switch amiType {
+	case ec2CapacityProviderAMITypeARM:
+		return "t4g.medium"
+	case ec2CapacityProviderAMITypeGPU:
+		return "g4dn.xlarge"
+	default:
+		return "t3.medium"
+	}
+}
+
+// ecsOptimizedAMI looks up the latest ECS-optimized AMI for the given AMI type from the public
+// Systems Manager parameters. GPU instances use the Amazon Linux 2 AMI, which is the only
+// ECS-optimized AMI with NVIDIA drivers.
+func ecsOptimizedAMI(ctx *pulumi.Context, amiType string, opts ...pulumi.InvokeOption) (string, error) {
+	var parameterName string
+	switch amiType {
+	case ec2CapacityProviderAMITypeX86:
+		parameterName = "/aws/service/ecs/optimized-ami/amazon-linux-2023/recommended/image_id"
+	case ec2CapacityProviderAMITypeARM:
+		parameterName = "/aws/service/ecs/optimized-ami/amazon-linux-2023/arm64/recommended/image_id"
+	case ec2CapacityProviderAMITypeGPU:
+		parameterName = "/aws/service/ecs/optimized-ami/amazon-linux-2/gpu/recommended/image_id"
+	default:
+		return "", fmt.Errorf("Unknown AMI type %s", amiType)
+	}
+
+	parameter, err := ssm.LookupParameter(ctx, &ssm.LookupParameterArgs{
+		Name: parameterName,
+	}, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return parameter.Value, nil
+}
+
+func ecsContainerInstanceUserData(clusterName, amiType, extraUserData string) string {
+	config := []string{
+		fmt.Sprintf("ECS_CLUSTER=%s", clusterName),
+		"ECS_ENABLE_CONTAINER_METADATA=true",
+	}
+
+	if amiType == ec2CapacityProviderAMITypeGPU {
+		config = append(config, "ECS_ENABLE_GPU_SUPPORT=true")
+	}
+
+	userData := fmt.Sprintf("#!/bin/bash\ncat >> /etc/ecs/ecs.config <<'EOF'\n%s\nEOF\n", strings.Join(config, "\n"))
+	if extraUserData != "" {
+		userData = fmt.Sprintf("%s\n%s\n", userData, extraUserData)
+	}
+
+	return userData
+}
+
+func ec2CapacityProviderInstanceTags(name string, tags map[string]string) map[string]string {
+	instanceTags := map[string]string{
+		"Name": name,
+	}
+	for tagKey, tagValue := range tags {
+		instanceTags[tagKey] = tagValue
+	}
+
+	return instanceTags
+}
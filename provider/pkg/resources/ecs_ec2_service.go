@@ -3,14 +3,18 @@ package resources
 import (
 	"fmt"
 
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/appautoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/servicediscovery"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 const EC2ServiceIdentifier = "awsx-go:ecs:EC2Service"
 
 type EC2ServiceArgs struct {
-	Cluster                         string                                        `pulumi:"cluster"`
+	AutoScaling                     *ServiceAutoScalingInputs                     `pulumi:"autoScaling"`
+	Cluster                         pulumi.StringInput                            `pulumi:"cluster"`
 	ContinueBeforeSteadyState       bool                                          `pulumi:"continueBeforeSteadyState"`
 	DeploymentCircuitBreaker        ecs.ServiceDeploymentCircuitBreakerPtrInput   `pulumi:"deploymentCircuitBreaker"`
 	DeploymentController            ecs.ServiceDeploymentControllerPtrInput       `pulumi:"deploymentController"`
@@ -30,6 +34,7 @@ type EC2ServiceArgs struct {
 	PlatformVersions                string                                        `pulumi:"platformVersions"`
 	PropagateTags                   string                                        `pulumi:"propagateTags"`
 	SchedulingStrategy              string                                        `pulumi:"schedulingStrategy"`
+	ServiceDiscovery                *ServiceDiscoveryInputs                       `pulumi:"serviceDiscovery"`
 	ServiceRegistries               ecs.ServiceServiceRegistriesPtrInput          `pulumi:"serviceRegistries"`
 	Tags                            map[string]string                             `pulumi:"tags"`
 	TaskDefinition                  string                                        `pulumi:"taskDefinition"`
@@ -39,8 +44,13 @@ type EC2ServiceArgs struct {
 type EC2Service struct {
 	pulumi.ResourceState
 
-	Service        *ecs.Service       `pulumi:"service"`
-	TaskDefinition *EC2TaskDefinition `pulumi:"taskDefinition"`
+	AutoScalingAlarms   []*cloudwatch.MetricAlarm             `pulumi:"autoScalingAlarms"`
+	AutoScalingPolicies []*appautoscaling.Policy              `pulumi:"autoScalingPolicies"`
+	AutoScalingTarget   *appautoscaling.Target                `pulumi:"autoScalingTarget"`
+	DiscoveryNamespace  *servicediscovery.PrivateDnsNamespace `pulumi:"discoveryNamespace"`
+	DiscoveryService    *servicediscovery.Service             `pulumi:"discoveryService"`
+	Service             *ecs.Service                          `pulumi:"service"`
+	TaskDefinition      *EC2TaskDefinition                    `pulumi:"taskDefinition"`
 }
 
 func NewEC2Service(ctx *pulumi.Context, name string, args *EC2ServiceArgs, opts ...pulumi.ResourceOption) (*EC2Service, error) {
@@ -61,10 +71,17 @@ func NewEC2Service(ctx *pulumi.Context, name string, args *EC2ServiceArgs, opts
 		return nil, fmt.Errorf("Only one of `taskDefinition` or `taskDefinitionArgs` can be provided.")
 	}
 
+	executeCommand, err := serviceExecuteCommand(ctx, name, args.EnableExecuteCommand, args.Cluster, args.TaskDefinition, opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	var taskDefinition *EC2TaskDefinition
 	taskDefinitionIdentifier := pulumi.String(args.TaskDefinition).ToStringPtrOutput()
 
 	if args.TaskDefinitionArgs != nil {
+		args.TaskDefinitionArgs.executeCommand = executeCommand
+
 		taskDefinition, err = NewEC2TaskDefinition(ctx, name, args.TaskDefinitionArgs, opts...)
 		if err != nil {
 			return nil, err
@@ -74,8 +91,51 @@ func NewEC2Service(ctx *pulumi.Context, name string, args *EC2ServiceArgs, opts
 		taskDefinitionIdentifier = taskDefinition.TaskDefinition.Arn.ToStringPtrOutput()
 	}
 
+	if args.LoadBalancers == nil && taskDefinition != nil {
+		args.LoadBalancers = taskDefinition.LoadBalancers
+	}
+
+	var cluster pulumi.StringPtrInput
+	if args.Cluster != nil {
+		cluster = args.Cluster.ToStringOutput().ToStringPtrOutput()
+	}
+
+	var containers map[string]TaskDefinitionContainerDefinitionInputs
+	networkMode := ""
+	if args.TaskDefinitionArgs != nil {
+		// The task definition is named after the service, as is its container if there is only one.
+		containers, err = resolveContainers(name, args.TaskDefinitionArgs.Container, args.TaskDefinitionArgs.Containers)
+		if err != nil {
+			return nil, err
+		}
+		networkMode = args.TaskDefinitionArgs.NetworkMode
+		if networkMode == "" {
+			networkMode = "bridge"
+		}
+	}
+
+	discovery, err := serviceDiscovery(ctx, name, args.ServiceDiscovery, args.ServiceRegistries, networkMode, containers, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.DiscoveryNamespace = discovery.Namespace
+	component.DiscoveryService = discovery.Service
+
+	serviceOpts := opts
+	if args.AutoScaling != nil {
+		err = args.AutoScaling.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		// Application Auto Scaling owns the desired count once the service exists.
+		args.DesiredCount = args.AutoScaling.InitialDesiredCount(args.DesiredCount)
+		serviceOpts = append(serviceOpts, pulumi.IgnoreChanges([]string{"desiredCount"}))
+	}
+
 	service, err := ecs.NewService(ctx, name, &ecs.ServiceArgs{
-		Cluster:                         pulumi.StringPtr(args.Cluster),
+		Cluster:                         cluster,
 		DeploymentCircuitBreaker:        args.DeploymentCircuitBreaker,
 		DeploymentController:            args.DeploymentController,
 		DeploymentMaximumPercent:        pulumi.IntPtr(args.DeploymentMaximumPercent),
@@ -94,15 +154,26 @@ func NewEC2Service(ctx *pulumi.Context, name string, args *EC2ServiceArgs, opts
 		PlatformVersion:                 pulumi.StringPtr(args.PlatformVersions),
 		PropagateTags:                   pulumi.StringPtr(args.PropagateTags),
 		SchedulingStrategy:              pulumi.StringPtr(args.SchedulingStrategy),
-		ServiceRegistries:               args.ServiceRegistries,
+		ServiceRegistries:               discovery.ServiceRegistries,
 		Tags:                            pulumi.ToStringMap(args.Tags),
 		TaskDefinition:                  taskDefinitionIdentifier,
-	}, opts...)
+	}, serviceOpts...)
 	if err != nil {
 		return nil, err
 	}
 
 	component.Service = service
 
+	if args.AutoScaling != nil {
+		autoScaling, err := serviceAutoScaling(ctx, name, args.AutoScaling, service, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.AutoScalingAlarms = autoScaling.Alarms
+		component.AutoScalingPolicies = autoScaling.Policies
+		component.AutoScalingTarget = autoScaling.Target
+	}
+
 	return component, nil
 }
@@ -1,12 +1,14 @@
 package resources
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecr"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/efs"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/secretsmanager"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/zchase/pulumi-awsx-go/pkg/utils"
 )
@@ -14,15 +16,20 @@ import (
 const EC2TaskDefinitionIdentifier = "awsx-go:ecs:EC2TaskDefinition"
 
 type EC2TaskDefinitionArgs struct {
+	Container             *TaskDefinitionContainerDefinitionInputs           `pulumi:"container"`
 	Containers            map[string]TaskDefinitionContainerDefinitionInputs `pulumi:"containers"`
 	CPU                   string                                             `pulumi:"cpu"`
 	EphemeralStorage      ecs.TaskDefinitionEphemeralStoragePtrInput         `pulumi:"ephemeralStorage"`
+	EFS                   *TaskDefinitionEFSInputs                           `pulumi:"efs"`
 	ExecutionRole         DefaultRoleWithPolicyInputs                        `pulumi:"executionRole"`
 	Family                string                                             `pulumi:"family"`
+	Grants                *TaskDefinitionGrantsInputs                        `pulumi:"grants"`
 	InferenceAccelerators ecs.TaskDefinitionInferenceAcceleratorArrayInput   `pulumi:"inferenceAccelerators"`
 	IPCMode               string                                             `pulumi:"ipcMode"`
 	LogGroup              DefaultLogGroupInputs                              `pulumi:"logGroup"`
+	LogRouter             *TaskDefinitionLogRouterInputs                     `pulumi:"logRouter"`
 	Memory                string                                             `pulumi:"memory"`
+	Observability         *TaskDefinitionObservabilityInputs                 `pulumi:"observability"`
 	NetworkMode           string                                             `pulumi:"networkMode"`
 	PIDMode               string                                             `pulumi:"pidMode"`
 	PlacementConstraints  ecs.TaskDefinitionPlacementConstraintArrayInput    `pulumi:"placementConstraints"`
@@ -32,15 +39,22 @@ type EC2TaskDefinitionArgs struct {
 	Tags                  map[string]string                                  `pulumi:"tags"`
 	TaskRole              DefaultRoleWithPolicyInputs                        `pulumi:"taskRole"`
 	Volumes               ecs.TaskDefinitionVolumeArrayInput                 `pulumi:"volumes"`
+
+	// executeCommand is set by the service running the tasks when ECS Exec is enabled.
+	executeCommand *serviceExecuteCommandConfiguration
 }
 
 type EC2TaskDefinition struct {
 	pulumi.ResourceState
 
+	AccessPoints   []*efs.AccessPoint                 `pulumi:"accessPoints"`
 	ExecutionRole  *iam.Role                          `pulumi:"executionRole"`
+	FileSystem     *efs.FileSystem                    `pulumi:"fileSystem"`
 	LoadBalancers  ecs.ServiceLoadBalancerArrayOutput `pulumi:"loadBalancers"`
 	LogGroup       *cloudwatch.LogGroup               `pulumi:"logGroup"`
+	Repository     *ecr.Repository                    `pulumi:"repository"`
 	TaskDefinition *ecs.TaskDefinition                `pulumi:"taskDefinition"`
+	Secrets        []*secretsmanager.Secret           `pulumi:"secrets"`
 	TaskRole       *iam.Role                          `pulumi:"taskRole"`
 }
 
@@ -57,56 +71,57 @@ func NewEC2TaskDefinition(ctx *pulumi.Context, name string, args *EC2TaskDefinit
 
 	opts = append(opts, pulumi.Parent(component))
 
-	dLogGroup, err := defaultLogGroup(ctx, name, &args.LogGroup, opts...)
+	taskContainers, err := buildTaskDefinitionContainers(ctx, name, &taskDefinitionContainersInputs{
+		Container:  args.Container,
+		Containers: args.Containers,
+		EFS:        args.EFS,
+		Lint: taskDefinitionLint{
+			Memory:      args.Memory,
+			NetworkMode: args.NetworkMode,
+		},
+		LogGroup:      &args.LogGroup,
+		LogRouter:     args.LogRouter,
+		Observability: args.Observability,
+		Tags:          args.Tags,
+	}, component, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	component.LogGroup = dLogGroup.LogGroup
-
-	taskRoleName := fmt.Sprintf("%s-task", name)
+	args.Containers = taskContainers.Containers
+	args.Volumes = mergeTaskDefinitionVolumes(args.Volumes, taskContainers.EFS.Volumes)
 
-	if len(args.TaskRole.Args.PolicyARNs) == 0 {
-		args.TaskRole.Args.PolicyARNs = defaultExecutionRolePolicyARNs()
+	component.AccessPoints = taskContainers.EFS.AccessPoints
+	component.FileSystem = taskContainers.EFS.FileSystem
+	component.Repository = taskContainers.Images.Repository
+	component.Secrets = taskContainers.Secrets.Secrets
+	if taskContainers.LogGroup != nil {
+		component.LogGroup = taskContainers.LogGroup.LogGroup
 	}
 
-	defaultPolicyDoc, err := defaultRoleAssumeRolePolicy(ctx)
+	roles, err := attachTaskRolePolicies(ctx, name, &taskDefinitionRolesInputs{
+		ExecuteCommand: args.executeCommand,
+		ExecutionRole:  &args.ExecutionRole,
+		Grants:         args.Grants,
+		TaskRole:       &args.TaskRole,
+	}, taskContainers, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	taskRole, err := defaultRoleWithPolicies(ctx, taskRoleName, args.TaskRole, defaultPolicyDoc.Json, opts...)
-	if err != nil {
-		return nil, err
-	}
-	component.TaskRole = taskRole.Role
+	component.ExecutionRole = roles.ExecutionRole.Role
+	component.TaskRole = roles.TaskRole.Role
 
-	executionRoleName := fmt.Sprintf("%s-execution", name)
+	containerDefinitions := computeContainerDefinitions(component, args.Containers, taskContainers.LogGroupID())
 
-	if len(args.ExecutionRole.Args.PolicyARNs) == 0 {
-		args.ExecutionRole.Args.PolicyARNs = defaultExecutionRolePolicyARNs()
-	}
+	component.LoadBalancers = computeLoadBalancers(containerDefinitions)
 
-	executionRole, err := defaultRoleWithPolicies(ctx, executionRoleName, args.ExecutionRole, defaultPolicyDoc.Json, opts...)
+	taskDefinitionArgs, err := buildTaskDefinitionArgs(ctx, name, args, containerDefinitions, roles.TaskRole.RoleARN, roles.ExecutionRole.RoleARN)
 	if err != nil {
 		return nil, err
 	}
-	component.ExecutionRole = executionRole.Role
-
-	containerDefinitions := computeContainerDefinitions(component, args.Containers, &dLogGroup.LogGroupID)
-
-	computeLoadBalancers(ctx, args.Containers).ApplyT(func(x interface{}) interface{} {
-		lbs := x.(ecs.ServiceLoadBalancerArrayOutput)
-		component.LoadBalancers = lbs
-		return nil
-	})
 
-	taskDefinitionArgs, err := buildTaskDefinitionArgs(ctx, name, args, containerDefinitions, taskRole.RoleARN, executionRole.RoleARN)
-	if err != nil {
-		return nil, err
-	}
-
-	taskDefinition, err := ecs.NewTaskDefinition(ctx, name, taskDefinitionArgs, opts...)
+	taskDefinition, err := ecs.NewTaskDefinition(ctx, name, taskDefinitionArgs, append(opts, pulumi.DependsOn(taskContainers.EFS.DependsOn()))...)
 	if err != nil {
 		return nil, err
 	}
@@ -117,23 +132,22 @@ func NewEC2TaskDefinition(ctx *pulumi.Context, name string, args *EC2TaskDefinit
 }
 
 func buildTaskDefinitionArgs(ctx *pulumi.Context, name string, args *EC2TaskDefinitionArgs, containerDefinitions []TaskDefinitionContainerDefinitionInputs, taskRoleARN, executionRoleARN pulumi.StringOutput) (*ecs.TaskDefinitionArgs, error) {
-	containerDefJSON, err := json.Marshal(containerDefinitions)
-	if err != nil {
-		return nil, err
-	}
+	containerDefJSON := resolveContainerDefinitions(containerDefinitions)
 
-	containerDefHash := utils.SHA1Hash(fmt.Sprintf("%s%s", ctx.Stack(), string(containerDefJSON)))
-	defaultFamily := fmt.Sprintf("%s-%s", name, containerDefHash)
+	family := pulumi.String(args.Family).ToStringOutput()
 	if args.Family == "" {
-		args.Family = defaultFamily
+		family = containerDefJSON.ApplyT(func(containerDefJSON string) string {
+			containerDefHash := utils.SHA1Hash(fmt.Sprintf("%s%s", ctx.Stack(), containerDefJSON))
+			return fmt.Sprintf("%s-%s", name, containerDefHash)
+		}).(pulumi.StringOutput)
 	}
 
 	return &ecs.TaskDefinitionArgs{
-		ContainerDefinitions:  pulumi.String(string(containerDefJSON)),
+		ContainerDefinitions:  containerDefJSON,
 		Cpu:                   pulumi.StringPtr(args.CPU),
 		EphemeralStorage:      args.EphemeralStorage,
 		ExecutionRoleArn:      executionRoleARN,
-		Family:                pulumi.String(args.Family),
+		Family:                family,
 		InferenceAccelerators: args.InferenceAccelerators,
 		IpcMode:               pulumi.StringPtr(args.IPCMode),
 		Memory:                pulumi.StringPtr(args.Memory),
@@ -0,0 +1,355 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/efs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type TaskDefinitionEFSPosixUserInputs struct {
+	GID           int   `pulumi:"gid"`
+	SecondaryGIDs []int `pulumi:"secondaryGids"`
+	UID           int   `pulumi:"uid"`
+}
+
+type TaskDefinitionEFSVolumeInputs struct {
+	ContainerPath            string                            `pulumi:"containerPath"`
+	Containers               []string                          `pulumi:"containers"`
+	PosixUser                *TaskDefinitionEFSPosixUserInputs `pulumi:"posixUser"`
+	ReadOnly                 bool                              `pulumi:"readOnly"`
+	RootDirectory            string                            `pulumi:"rootDirectory"`
+	RootDirectoryPermissions string                            `pulumi:"rootDirectoryPermissions"`
+}
+
+type TaskDefinitionEFSInputs struct {
+	FileSystemID     pulumi.StringInput                       `pulumi:"fileSystemId"`
+	SecurityGroupIDs pulumi.StringArrayInput                  `pulumi:"securityGroupIds"`
+	SubnetIDs        []string                                 `pulumi:"subnetIds"`
+	Volumes          map[string]TaskDefinitionEFSVolumeInputs `pulumi:"volumes"`
+}
+
+type taskDefinitionEFSVolume struct {
+	AccessPoint *efs.AccessPoint
+	ReadOnly    bool
+}
+
+type taskDefinitionEFSResult struct {
+	AccessPoints  []*efs.AccessPoint
+	FileSystem    *efs.FileSystem
+	MountTargets  []*efs.MountTarget
+	SecurityGroup *ec2.SecurityGroup
+	Volumes       ecs.TaskDefinitionVolumeArray
+
+	volumes []taskDefinitionEFSVolume
+}
+
+// DependsOn lists the mount targets tasks need before they can mount the file system.
+func (r *taskDefinitionEFSResult) DependsOn() []pulumi.Resource {
+	var resources []pulumi.Resource
+	for _, mountTarget := range r.MountTargets {
+		resources = append(resources, mountTarget)
+	}
+
+	return resources
+}
+
+// taskDefinitionEFS creates an EFS file system, reachable through mount targets in the given subnets,
+// unless an existing file system is given, and an access point for every volume. The volumes are
+// mounted into their containers with transit encryption and IAM authorization.
+func taskDefinitionEFS(ctx *pulumi.Context, name string, inputs *TaskDefinitionEFSInputs, containers map[string]TaskDefinitionContainerDefinitionInputs, tags map[string]string, opts ...pulumi.ResourceOption) (*taskDefinitionEFSResult, error) {
+	result := &taskDefinitionEFSResult{}
+	if inputs == nil {
+		return result, nil
+	}
+
+	if len(inputs.Volumes) == 0 {
+		return nil, fmt.Errorf("[efs] requires at least one of [volumes]")
+	}
+
+	var volumeNames []string
+	for volumeName := range inputs.Volumes {
+		volumeNames = append(volumeNames, volumeName)
+	}
+	sort.Strings(volumeNames)
+
+	for _, volumeName := range volumeNames {
+		volume := inputs.Volumes[volumeName]
+		if volume.ContainerPath == "" {
+			return nil, fmt.Errorf("EFS volume %s: [containerPath] must be specified", volumeName)
+		}
+
+		for _, containerName := range volume.Containers {
+			if _, ok := containers[containerName]; !ok {
+				return nil, fmt.Errorf("EFS volume %s: container %s does not exist", volumeName, containerName)
+			}
+		}
+	}
+
+	fileSystemID := inputs.FileSystemID
+	if fileSystemID == nil {
+		if len(inputs.SubnetIDs) == 0 {
+			return nil, fmt.Errorf("[efs] [subnetIds] must be specified to create a file system")
+		}
+
+		fileSystem, err := efs.NewFileSystem(ctx, name, &efs.FileSystemArgs{
+			Encrypted: pulumi.BoolPtr(true),
+			Tags:      pulumi.ToStringMap(tags),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.FileSystem = fileSystem
+		fileSystemID = fileSystem.ID()
+
+		subnets, err := efsMountTargetSubnets(ctx, inputs.SubnetIDs, fileSystem)
+		if err != nil {
+			return nil, err
+		}
+
+		securityGroup, err := efsMountTargetSecurityGroup(ctx, name, inputs, subnets.VpcID, tags, fileSystem)
+		if err != nil {
+			return nil, err
+		}
+
+		result.SecurityGroup = securityGroup
+
+		for _, subnetID := range subnets.SubnetIDs {
+			mountTarget, err := efs.NewMountTarget(ctx, fmt.Sprintf("%s-%s", name, subnetID), &efs.MountTargetArgs{
+				FileSystemId:   fileSystem.ID(),
+				SecurityGroups: pulumi.StringArray{securityGroup.ID()},
+				SubnetId:       pulumi.String(subnetID),
+			}, pulumi.Parent(fileSystem))
+			if err != nil {
+				return nil, err
+			}
+
+			result.MountTargets = append(result.MountTargets, mountTarget)
+		}
+	}
+
+	for _, volumeName := range volumeNames {
+		volume := inputs.Volumes[volumeName]
+
+		rootDirectory := volume.RootDirectory
+		if rootDirectory == "" {
+			rootDirectory = fmt.Sprintf("/%s", volumeName)
+		}
+
+		permissions := volume.RootDirectoryPermissions
+		if permissions == "" {
+			permissions = "0755"
+		}
+
+		// The root directory is created, owned by the POSIX user, the first time it is mounted.
+		creationInfo := &efs.AccessPointRootDirectoryCreationInfoArgs{
+			OwnerGid:    pulumi.Int(0),
+			OwnerUid:    pulumi.Int(0),
+			Permissions: pulumi.String(permissions),
+		}
+
+		var posixUser efs.AccessPointPosixUserPtrInput
+		if volume.PosixUser != nil {
+			creationInfo.OwnerGid = pulumi.Int(volume.PosixUser.GID)
+			creationInfo.OwnerUid = pulumi.Int(volume.PosixUser.UID)
+			posixUser = &efs.AccessPointPosixUserArgs{
+				Gid:           pulumi.Int(volume.PosixUser.GID),
+				SecondaryGids: pulumi.ToIntArray(volume.PosixUser.SecondaryGIDs),
+				Uid:           pulumi.Int(volume.PosixUser.UID),
+			}
+		}
+
+		accessPoint, err := efs.NewAccessPoint(ctx, fmt.Sprintf("%s-%s", name, volumeName), &efs.AccessPointArgs{
+			FileSystemId: fileSystemID,
+			PosixUser:    posixUser,
+			RootDirectory: &efs.AccessPointRootDirectoryArgs{
+				CreationInfo: creationInfo,
+				Path:         pulumi.String(rootDirectory),
+			},
+			Tags: pulumi.ToStringMap(tags),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.AccessPoints = append(result.AccessPoints, accessPoint)
+		result.volumes = append(result.volumes, taskDefinitionEFSVolume{
+			AccessPoint: accessPoint,
+			ReadOnly:    volume.ReadOnly,
+		})
+
+		result.Volumes = append(result.Volumes, &ecs.TaskDefinitionVolumeArgs{
+			Name: pulumi.String(volumeName),
+			EfsVolumeConfiguration: &ecs.TaskDefinitionVolumeEfsVolumeConfigurationArgs{
+				AuthorizationConfig: &ecs.TaskDefinitionVolumeEfsVolumeConfigurationAuthorizationConfigArgs{
+					AccessPointId: accessPoint.ID(),
+					Iam:           pulumi.StringPtr("ENABLED"),
+				},
+				FileSystemId:      fileSystemID,
+				TransitEncryption: pulumi.StringPtr("ENABLED"),
+			},
+		})
+
+		containerNames := volume.Containers
+		if len(containerNames) == 0 {
+			for containerName := range containers {
+				containerNames = append(containerNames, containerName)
+			}
+		}
+
+		for _, containerName := range containerNames {
+			container := containers[containerName]
+			container.MountPoints = append(container.MountPoints, TaskDefinitionMountPointInputs{
+				ContainerPath: volume.ContainerPath,
+				ReadOnly:      volume.ReadOnly,
+				SourceVolume:  volumeName,
+			})
+			containers[containerName] = container
+		}
+	}
+
+	return result, nil
+}
+
+type efsMountTargetSubnetsResult struct {
+	SubnetIDs []string
+	VpcID     string
+}
+
+// efsMountTargetSubnets picks the first of the given subnets in every availability zone, as a file
+// system has at most one mount target per availability zone.
+func efsMountTargetSubnets(ctx *pulumi.Context, subnetIDs []string, parent pulumi.Resource) (*efsMountTargetSubnetsResult, error) {
+	result := &efsMountTargetSubnetsResult{}
+
+	availabilityZones := map[string]bool{}
+	for _, subnetID := range subnetIDs {
+		subnet, err := ec2.LookupSubnet(ctx, &ec2.LookupSubnetArgs{
+			Id: pulumi.StringRef(subnetID),
+		}, pulumi.Parent(parent))
+		if err != nil {
+			return nil, err
+		}
+
+		if result.VpcID != "" && result.VpcID != subnet.VpcId {
+			return nil, fmt.Errorf("[efs] subnets %s and %s are in different VPCs", subnetIDs[0], subnetID)
+		}
+		result.VpcID = subnet.VpcId
+
+		if availabilityZones[subnet.AvailabilityZone] {
+			continue
+		}
+		availabilityZones[subnet.AvailabilityZone] = true
+
+		result.SubnetIDs = append(result.SubnetIDs, subnetID)
+	}
+
+	return result, nil
+}
+
+// efsMountTargetSecurityGroup allows NFS traffic to the mount targets from the security groups of the
+// tasks, or from the whole VPC if there are none.
+func efsMountTargetSecurityGroup(ctx *pulumi.Context, name string, inputs *TaskDefinitionEFSInputs, vpcID string, tags map[string]string, parent pulumi.Resource) (*ec2.SecurityGroup, error) {
+	vpc, err := ec2.LookupVpc(ctx, &ec2.LookupVpcArgs{
+		Id: pulumi.StringRef(vpcID),
+	}, pulumi.Parent(parent))
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroupIDs := pulumi.StringArray{}.ToStringArrayOutput()
+	if inputs.SecurityGroupIDs != nil {
+		securityGroupIDs = inputs.SecurityGroupIDs.ToStringArrayOutput()
+	}
+
+	// The security groups of a service are only known once its network configuration is resolved.
+	ingress := securityGroupIDs.ApplyT(func(securityGroupIDs []string) []ec2.SecurityGroupIngress {
+		rule := ec2.SecurityGroupIngress{
+			FromPort: 2049,
+			Protocol: "tcp",
+			ToPort:   2049,
+		}
+
+		if len(securityGroupIDs) > 0 {
+			rule.SecurityGroups = securityGroupIDs
+		} else {
+			rule.CidrBlocks = []string{vpc.CidrBlock}
+		}
+
+		return []ec2.SecurityGroupIngress{rule}
+	}).(ec2.SecurityGroupIngressArrayOutput)
+
+	return ec2.NewSecurityGroup(ctx, fmt.Sprintf("%s-efs", name), &ec2.SecurityGroupArgs{
+		Ingress: ingress,
+		Tags:    pulumi.ToStringMap(tags),
+		VpcId:   pulumi.String(vpcID),
+	}, pulumi.Parent(parent))
+}
+
+// mergeTaskDefinitionVolumes adds the EFS volumes to the volumes given to the task definition.
+func mergeTaskDefinitionVolumes(volumes ecs.TaskDefinitionVolumeArrayInput, efsVolumes ecs.TaskDefinitionVolumeArray) ecs.TaskDefinitionVolumeArrayInput {
+	if len(efsVolumes) == 0 {
+		return volumes
+	}
+
+	if volumes == nil {
+		return efsVolumes
+	}
+
+	return pulumi.All(volumes.ToTaskDefinitionVolumeArrayOutput(), efsVolumes.ToTaskDefinitionVolumeArrayOutput()).ApplyT(func(args []interface{}) []ecs.TaskDefinitionVolume {
+		return append(args[0].([]ecs.TaskDefinitionVolume), args[1].([]ecs.TaskDefinitionVolume)...)
+	}).(ecs.TaskDefinitionVolumeArrayOutput)
+}
+
+// taskRoleEFSPolicy allows the task role to mount the file system through the access point of each
+// volume, and to write to the volumes that are not read only.
+func taskRoleEFSPolicy(ctx *pulumi.Context, name string, result *taskDefinitionEFSResult, taskRole *defaultRoleWithPoliciesResult, opts ...pulumi.ResourceOption) (*iam.RolePolicy, error) {
+	if len(result.volumes) == 0 {
+		return nil, nil
+	}
+
+	// Volumes are mounted with IAM authorization, which only a task role the task definition creates
+	// can be granted.
+	if taskRole == nil || taskRole.Role == nil {
+		return nil, fmt.Errorf("[efs] can only be used with a task role created by the task definition")
+	}
+
+	var arns []interface{}
+	for _, volume := range result.volumes {
+		arns = append(arns, volume.AccessPoint.FileSystemArn, volume.AccessPoint.Arn)
+	}
+
+	volumes := result.volumes
+	policy := pulumi.All(arns...).ApplyT(func(values []interface{}) (string, error) {
+		var statements []iam.GetPolicyDocumentStatement
+		for i, volume := range volumes {
+			actions := []string{"elasticfilesystem:ClientMount"}
+			if !volume.ReadOnly {
+				actions = append(actions, "elasticfilesystem:ClientWrite")
+			}
+
+			statements = append(statements, iam.GetPolicyDocumentStatement{
+				Actions:   actions,
+				Resources: []string{values[2*i].(string)},
+				Conditions: []iam.GetPolicyDocumentStatementCondition{
+					{
+						Test:     "StringEquals",
+						Variable: "elasticfilesystem:AccessPointArn",
+						Values:   []string{values[2*i+1].(string)},
+					},
+				},
+			})
+		}
+
+		return allowPolicyDocument(ctx, statements)
+	}).(pulumi.StringOutput)
+
+	return iam.NewRolePolicy(ctx, fmt.Sprintf("%s-efs", name), &iam.RolePolicyArgs{
+		Role:   taskRole.Role.Name,
+		Policy: policy,
+	}, append(opts, pulumi.Parent(taskRole.Role))...)
+}
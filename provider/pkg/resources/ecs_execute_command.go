@@ -0,0 +1,151 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// serviceExecuteCommandConfiguration holds the encryption and logging settings of the ECS Exec
+// configuration of the cluster a service runs on, which the task role needs access to for sessions to
+// start. Settings the cluster does not configure are empty.
+type serviceExecuteCommandConfiguration struct {
+	KMSKeyID     pulumi.StringOutput
+	LogGroupName pulumi.StringOutput
+	S3BucketName pulumi.StringOutput
+	S3KeyPrefix  pulumi.StringOutput
+}
+
+// serviceExecuteCommand reads the ECS Exec configuration of the cluster of a service that enables ECS
+// Exec, to be passed to the task definition the service creates. Services on the default cluster, which
+// has no ECS Exec configuration, only need access to the SSM agent.
+func serviceExecuteCommand(ctx *pulumi.Context, name string, enableExecuteCommand bool, cluster pulumi.StringInput, taskDefinition string, opts ...pulumi.ResourceOption) (*serviceExecuteCommandConfiguration, error) {
+	if !enableExecuteCommand {
+		return nil, nil
+	}
+
+	if taskDefinition != "" {
+		return nil, fmt.Errorf("[enableExecuteCommand] requires the task definition to be created by the service, grant the task role of the [taskDefinition] access to ECS Exec instead")
+	}
+
+	empty := pulumi.String("").ToStringOutput()
+	if cluster == nil {
+		return &serviceExecuteCommandConfiguration{
+			KMSKeyID:     empty,
+			LogGroupName: empty,
+			S3BucketName: empty,
+			S3KeyPrefix:  empty,
+		}, nil
+	}
+
+	// The ID of a cluster is its ARN.
+	clusterID := cluster.ToStringOutput().ApplyT(func(arn string) pulumi.ID {
+		return pulumi.ID(arn)
+	}).(pulumi.IDOutput)
+
+	existing, err := ecs.GetCluster(ctx, fmt.Sprintf("%s-cluster", name), clusterID, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	configuration := existing.Configuration.ExecuteCommandConfiguration()
+	logConfiguration := configuration.LogConfiguration()
+
+	return &serviceExecuteCommandConfiguration{
+		KMSKeyID:     configuration.KmsKeyId().Elem(),
+		LogGroupName: logConfiguration.CloudWatchLogGroupName().Elem(),
+		S3BucketName: logConfiguration.S3BucketName().Elem(),
+		S3KeyPrefix:  logConfiguration.S3KeyPrefix().Elem(),
+	}, nil
+}
+
+// taskRoleExecuteCommandPolicy allows the task role to open ECS Exec sessions through the SSM agent of
+// the containers, and to decrypt and log them the way the cluster is configured to.
+func taskRoleExecuteCommandPolicy(ctx *pulumi.Context, name string, configuration *serviceExecuteCommandConfiguration, taskRole *defaultRoleWithPoliciesResult, opts ...pulumi.ResourceOption) (*iam.RolePolicy, error) {
+	if configuration == nil {
+		return nil, nil
+	}
+
+	if taskRole == nil || taskRole.Role == nil {
+		return nil, fmt.Errorf("[enableExecuteCommand] can only be used with a task role created by the task definition")
+	}
+
+	prefix, err := arnPrefix(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := pulumi.All(configuration.KMSKeyID, configuration.LogGroupName, configuration.S3BucketName, configuration.S3KeyPrefix).ApplyT(func(values []interface{}) (string, error) {
+		kmsKeyID := values[0].(string)
+		logGroupName := values[1].(string)
+		s3BucketName := values[2].(string)
+		s3KeyPrefix := strings.Trim(values[3].(string), "/")
+
+		statements := []iam.GetPolicyDocumentStatement{
+			{
+				Actions: []string{
+					"ssmmessages:CreateControlChannel",
+					"ssmmessages:CreateDataChannel",
+					"ssmmessages:OpenControlChannel",
+					"ssmmessages:OpenDataChannel",
+				},
+				Resources: []string{"*"},
+			},
+		}
+
+		if kmsKeyID != "" {
+			keyARN := kmsKeyID
+			if !strings.HasPrefix(kmsKeyID, "arn:") {
+				keyARN = fmt.Sprintf("%s:key/%s", prefix.For("kms"), kmsKeyID)
+			}
+
+			statements = append(statements, iam.GetPolicyDocumentStatement{
+				Actions:   []string{"kms:Decrypt"},
+				Resources: []string{keyARN},
+			})
+		}
+
+		if logGroupName != "" {
+			logGroupARN := fmt.Sprintf("%s:log-group:%s", prefix.For("logs"), logGroupName)
+			statements = append(statements,
+				iam.GetPolicyDocumentStatement{
+					Actions:   []string{"logs:DescribeLogGroups"},
+					Resources: []string{"*"},
+				},
+				iam.GetPolicyDocumentStatement{
+					Actions:   []string{"logs:CreateLogStream", "logs:DescribeLogStreams", "logs:PutLogEvents"},
+					Resources: []string{logGroupARN, fmt.Sprintf("%s:*", logGroupARN)},
+				},
+			)
+		}
+
+		if s3BucketName != "" {
+			bucketARN := fmt.Sprintf("arn:%s:s3:::%s", prefix.Partition, s3BucketName)
+			objectARN := fmt.Sprintf("%s/*", bucketARN)
+			if s3KeyPrefix != "" {
+				objectARN = fmt.Sprintf("%s/%s/*", bucketARN, s3KeyPrefix)
+			}
+
+			statements = append(statements,
+				iam.GetPolicyDocumentStatement{
+					Actions:   []string{"s3:GetEncryptionConfiguration"},
+					Resources: []string{bucketARN},
+				},
+				iam.GetPolicyDocumentStatement{
+					Actions:   []string{"s3:PutObject"},
+					Resources: []string{objectARN},
+				},
+			)
+		}
+
+		return allowPolicyDocument(ctx, statements)
+	}).(pulumi.StringOutput)
+
+	return iam.NewRolePolicy(ctx, fmt.Sprintf("%s-exec", name), &iam.RolePolicyArgs{
+		Role:   taskRole.Role.Name,
+		Policy: policy,
+	}, append(opts, pulumi.Parent(taskRole.Role))...)
+}
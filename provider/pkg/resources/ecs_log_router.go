@@ -0,0 +1,251 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/zchase/pulumi-awsx-go/pkg/utils"
+)
+
+const (
+	defaultLogRouterImage = "public.ecr.aws/aws-observability/aws-for-fluent-bit:stable"
+	defaultLogRouterName  = "log-router"
+)
+
+type TaskDefinitionLogRouterCloudWatchInputs struct {
+	LogGroupName    string `pulumi:"logGroupName"`
+	LogStreamPrefix string `pulumi:"logStreamPrefix"`
+}
+
+type TaskDefinitionLogRouterFirehoseInputs struct {
+	DeliveryStream string `pulumi:"deliveryStream"`
+}
+
+type TaskDefinitionLogRouterOpenSearchInputs struct {
+	DomainArn string `pulumi:"domainArn"`
+	Endpoint  string `pulumi:"endpoint"`
+	Index     string `pulumi:"index"`
+}
+
+type TaskDefinitionLogRouterS3Inputs struct {
+	Bucket    string `pulumi:"bucket"`
+	KeyPrefix string `pulumi:"keyPrefix"`
+}
+
+type TaskDefinitionLogRouterInputs struct {
+	CloudWatch        *TaskDefinitionLogRouterCloudWatchInputs `pulumi:"cloudWatch"`
+	Firehose          *TaskDefinitionLogRouterFirehoseInputs   `pulumi:"firehose"`
+	Image             string                                   `pulumi:"image"`
+	MemoryReservation int                                      `pulumi:"memoryReservation"`
+	Name              string                                   `pulumi:"name"`
+	OpenSearch        *TaskDefinitionLogRouterOpenSearchInputs `pulumi:"openSearch"`
+	S3                *TaskDefinitionLogRouterS3Inputs         `pulumi:"s3"`
+}
+
+type taskDefinitionLogRouterResult struct {
+	Actions   []string
+	Resources pulumi.StringArrayOutput
+}
+
+// taskDefinitionLogRouter adds a Fluent Bit FireLens container to the task, and routes the logs of the
+// containers without a log configuration through it to the destination of the preset.
+func taskDefinitionLogRouter(ctx *pulumi.Context, inputs *TaskDefinitionLogRouterInputs, containers map[string]TaskDefinitionContainerDefinitionInputs, logGroup *LogGroupResult, opts ...pulumi.ResourceOption) (*taskDefinitionLogRouterResult, error) {
+	if inputs == nil {
+		return nil, nil
+	}
+
+	presets := 0
+	for _, isSpecified := range []bool{inputs.CloudWatch != nil, inputs.Firehose != nil, inputs.OpenSearch != nil, inputs.S3 != nil} {
+		if isSpecified {
+			presets++
+		}
+	}
+
+	if presets != 1 {
+		return nil, fmt.Errorf("Exactly one of [cloudWatch], [firehose], [openSearch] or [s3] must be specified for the [logRouter]")
+	}
+
+	routerName := inputs.Name
+	if routerName == "" {
+		routerName = defaultLogRouterName
+	}
+
+	if _, ok := containers[routerName]; ok {
+		return nil, fmt.Errorf("Container %s already exists, specify another [name] for the [logRouter]", routerName)
+	}
+
+	prefix, err := arnPrefix(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &taskDefinitionLogRouterResult{}
+	var options map[string]pulumi.StringInput
+
+	switch {
+	case inputs.CloudWatch != nil:
+		logGroupName := pulumi.String(inputs.CloudWatch.LogGroupName).ToStringOutput()
+		logGroupARN := pulumi.String(fmt.Sprintf("%s:log-group:%s", prefix.For("logs"), inputs.CloudWatch.LogGroupName)).ToStringOutput()
+		if inputs.CloudWatch.LogGroupName == "" {
+			if logGroup == nil {
+				return nil, fmt.Errorf("[logRouter] [cloudWatch] requires a [logGroupName] when the log group of the task definition is skipped")
+			}
+
+			logGroupName = utils.ApplyAny(logGroup.LogGroupID, func(l LogGroupID) pulumi.StringOutput {
+				return l.LogGroupName
+			})
+			logGroupARN = utils.ApplyAny(logGroup.LogGroupID, func(l LogGroupID) pulumi.StringOutput {
+				return l.ARN
+			})
+		}
+
+		logStreamPrefix := inputs.CloudWatch.LogStreamPrefix
+		if logStreamPrefix == "" {
+			logStreamPrefix = "firelens-"
+		}
+
+		options = map[string]pulumi.StringInput{
+			"Name":              pulumi.String("cloudwatch_logs"),
+			"region":            pulumi.String(prefix.Region),
+			"log_group_name":    logGroupName,
+			"log_stream_prefix": pulumi.String(logStreamPrefix),
+		}
+
+		result.Actions = []string{"logs:CreateLogStream", "logs:DescribeLogStreams", "logs:PutLogEvents"}
+		result.Resources = logGroupARN.ApplyT(func(arn string) []string {
+			arn = strings.TrimSuffix(arn, ":*")
+			return []string{arn, fmt.Sprintf("%s:*", arn)}
+		}).(pulumi.StringArrayOutput)
+	case inputs.Firehose != nil:
+		if inputs.Firehose.DeliveryStream == "" {
+			return nil, fmt.Errorf("[logRouter] [firehose] requires a [deliveryStream]")
+		}
+
+		options = map[string]pulumi.StringInput{
+			"Name":            pulumi.String("kinesis_firehose"),
+			"region":          pulumi.String(prefix.Region),
+			"delivery_stream": pulumi.String(inputs.Firehose.DeliveryStream),
+		}
+
+		result.Actions = []string{"firehose:PutRecordBatch"}
+		result.Resources = pulumi.ToStringArray([]string{
+			fmt.Sprintf("%s:deliverystream/%s", prefix.For("firehose"), inputs.Firehose.DeliveryStream),
+		}).ToStringArrayOutput()
+	case inputs.OpenSearch != nil:
+		if inputs.OpenSearch.DomainArn == "" || inputs.OpenSearch.Endpoint == "" {
+			return nil, fmt.Errorf("[logRouter] [openSearch] requires a [domainArn] and an [endpoint]")
+		}
+
+		index := inputs.OpenSearch.Index
+		if index == "" {
+			index = "logs"
+		}
+
+		options = map[string]pulumi.StringInput{
+			"Name":               pulumi.String("opensearch"),
+			"AWS_Auth":           pulumi.String("On"),
+			"AWS_Region":         pulumi.String(prefix.Region),
+			"Host":               pulumi.String(strings.TrimPrefix(inputs.OpenSearch.Endpoint, "https://")),
+			"Index":              pulumi.String(index),
+			"Port":               pulumi.String("443"),
+			"Suppress_Type_Name": pulumi.String("On"),
+			"tls":                pulumi.String("On"),
+		}
+
+		result.Actions = []string{"es:ESHttpPost", "es:ESHttpPut"}
+		result.Resources = pulumi.ToStringArray([]string{
+			fmt.Sprintf("%s/*", inputs.OpenSearch.DomainArn),
+		}).ToStringArrayOutput()
+	case inputs.S3 != nil:
+		if inputs.S3.Bucket == "" {
+			return nil, fmt.Errorf("[logRouter] [s3] requires a [bucket]")
+		}
+
+		keyPrefix := strings.Trim(inputs.S3.KeyPrefix, "/")
+		if keyPrefix == "" {
+			keyPrefix = "logs"
+		}
+
+		options = map[string]pulumi.StringInput{
+			"Name":            pulumi.String("s3"),
+			"region":          pulumi.String(prefix.Region),
+			"bucket":          pulumi.String(inputs.S3.Bucket),
+			"s3_key_format":   pulumi.String(fmt.Sprintf("/%s/$TAG/%%Y/%%m/%%d/%%H/%%M/%%S", keyPrefix)),
+			"total_file_size": pulumi.String("10M"),
+			"upload_timeout":  pulumi.String("1m"),
+		}
+
+		result.Actions = []string{"s3:PutObject"}
+		result.Resources = pulumi.ToStringArray([]string{
+			fmt.Sprintf("arn:%s:s3:::%s/%s/*", prefix.Partition, inputs.S3.Bucket, keyPrefix),
+		}).ToStringArrayOutput()
+	}
+
+	for containerName, container := range containers {
+		if container.LogConfiguration != nil {
+			continue
+		}
+
+		container.LogConfiguration = &TaskDefinitionLogConfigurationInputs{
+			LogDriver: "awsfirelens",
+			Options:   options,
+		}
+
+		// Logs are lost if the container starts before the router.
+		container.DependsOn = append(container.DependsOn, TaskDefinitionContainerDependencyInputs{
+			Condition:     "START",
+			ContainerName: routerName,
+		})
+		containers[containerName] = container
+	}
+
+	image := inputs.Image
+	if image == "" {
+		image = defaultLogRouterImage
+	}
+
+	memoryReservation := inputs.MemoryReservation
+	if memoryReservation == 0 {
+		memoryReservation = 50
+	}
+
+	essential := true
+	containers[routerName] = TaskDefinitionContainerDefinitionInputs{
+		Essential: &essential,
+		FirelensConfiguration: &TaskDefinitionFirelensConfigurationInputs{
+			Options: map[string]string{
+				"enable-ecs-log-metadata": "true",
+			},
+			Type: "fluentbit",
+		},
+		Image:             image,
+		MemoryReservation: memoryReservation,
+	}
+
+	return result, nil
+}
+
+// taskRoleLogRouterPolicy allows the task role to deliver the routed logs to the destination of the
+// log router.
+func taskRoleLogRouterPolicy(ctx *pulumi.Context, name string, logRouter *taskDefinitionLogRouterResult, taskRole *defaultRoleWithPoliciesResult, opts ...pulumi.ResourceOption) (*iam.RolePolicy, error) {
+	if logRouter == nil || taskRole == nil || taskRole.Role == nil {
+		return nil, nil
+	}
+
+	actions := logRouter.Actions
+	policy := logRouter.Resources.ApplyT(func(resources []string) (string, error) {
+		return allowPolicyDocument(ctx, []iam.GetPolicyDocumentStatement{
+			{
+				Actions:   actions,
+				Resources: resources,
+			},
+		})
+	}).(pulumi.StringOutput)
+
+	return iam.NewRolePolicy(ctx, fmt.Sprintf("%s-log-router", name), &iam.RolePolicyArgs{
+		Role:   taskRole.Role.Name,
+		Policy: policy,
+	}, append(opts, pulumi.Parent(taskRole.Role))...)
+}
@@ -0,0 +1,313 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	defaultObservabilityCollectorImage    = "public.ecr.aws/aws-observability/aws-otel-collector:v0.40.0"
+	defaultObservabilityCollectorName     = "aws-otel-collector"
+	defaultObservabilityMetricsLogGroup   = "/aws/ecs/application/metrics"
+	defaultObservabilityMetricsNamespace  = "ECS/AWSOTel/Application"
+	defaultObservabilityPrometheusPath    = "/metrics"
+	defaultObservabilityPrometheusSeconds = 30
+)
+
+type TaskDefinitionObservabilityPrometheusInputs struct {
+	MetricsPath           string `pulumi:"metricsPath"`
+	Port                  int    `pulumi:"port"`
+	ScrapeIntervalSeconds int    `pulumi:"scrapeIntervalSeconds"`
+}
+
+type TaskDefinitionObservabilityInputs struct {
+	Containers        []string                                     `pulumi:"containers"`
+	Image             string                                       `pulumi:"image"`
+	MemoryReservation int                                          `pulumi:"memoryReservation"`
+	Metrics           bool                                         `pulumi:"metrics"`
+	MetricsLogGroup   string                                       `pulumi:"metricsLogGroup"`
+	MetricsNamespace  string                                       `pulumi:"metricsNamespace"`
+	Name              string                                       `pulumi:"name"`
+	Prometheus        *TaskDefinitionObservabilityPrometheusInputs `pulumi:"prometheus"`
+	Traces            bool                                         `pulumi:"traces"`
+}
+
+type taskDefinitionObservabilityResult struct {
+	Statements []iam.GetPolicyDocumentStatement
+}
+
+// taskDefinitionObservability adds an AWS Distro for OpenTelemetry collector container to the task,
+// configured to export traces to X-Ray and metrics, received over OTLP or scraped from a Prometheus
+// endpoint, to CloudWatch as embedded metric format logs. The instrumented containers are pointed at
+// the collector through the standard OTEL_* environment variables.
+func taskDefinitionObservability(ctx *pulumi.Context, inputs *TaskDefinitionObservabilityInputs, containers map[string]TaskDefinitionContainerDefinitionInputs, opts ...pulumi.ResourceOption) (*taskDefinitionObservabilityResult, error) {
+	if inputs == nil {
+		return nil, nil
+	}
+
+	if !inputs.Traces && !inputs.Metrics && inputs.Prometheus == nil {
+		return nil, fmt.Errorf("At least one of [traces], [metrics] or [prometheus] must be enabled for [observability]")
+	}
+
+	if inputs.Prometheus != nil && inputs.Prometheus.Port == 0 {
+		return nil, fmt.Errorf("[observability] [prometheus] requires a [port]")
+	}
+
+	collectorName := inputs.Name
+	if collectorName == "" {
+		collectorName = defaultObservabilityCollectorName
+	}
+
+	if _, ok := containers[collectorName]; ok {
+		return nil, fmt.Errorf("Container %s already exists, specify another [name] for the [observability] collector", collectorName)
+	}
+
+	for _, containerName := range inputs.Containers {
+		container, ok := containers[containerName]
+		if !ok {
+			return nil, fmt.Errorf("[observability] container %s does not exist", containerName)
+		}
+
+		if container.FirelensConfiguration != nil {
+			return nil, fmt.Errorf("[observability] container %s is a FireLens log router and cannot be instrumented", containerName)
+		}
+	}
+
+	metricsLogGroup := inputs.MetricsLogGroup
+	if metricsLogGroup == "" {
+		metricsLogGroup = defaultObservabilityMetricsLogGroup
+	}
+
+	metricsNamespace := inputs.MetricsNamespace
+	if metricsNamespace == "" {
+		metricsNamespace = defaultObservabilityMetricsNamespace
+	}
+
+	config, err := observabilityCollectorConfig(inputs, collectorName, metricsLogGroup, metricsNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &taskDefinitionObservabilityResult{}
+	if inputs.Traces {
+		// The actions of the AWSXRayDaemonWriteAccess managed policy.
+		result.Statements = append(result.Statements, iam.GetPolicyDocumentStatement{
+			Actions: []string{
+				"xray:GetSamplingRules",
+				"xray:GetSamplingStatisticSummaries",
+				"xray:GetSamplingTargets",
+				"xray:PutTelemetryRecords",
+				"xray:PutTraceSegments",
+			},
+			Resources: []string{"*"},
+		})
+	}
+
+	if inputs.Metrics || inputs.Prometheus != nil {
+		prefix, err := arnPrefix(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		logGroupARN := fmt.Sprintf("%s:log-group:%s", prefix.For("logs"), metricsLogGroup)
+		result.Statements = append(result.Statements, iam.GetPolicyDocumentStatement{
+			Actions: []string{
+				"logs:CreateLogGroup",
+				"logs:CreateLogStream",
+				"logs:DescribeLogStreams",
+				"logs:PutLogEvents",
+				"logs:PutRetentionPolicy",
+			},
+			Resources: []string{logGroupARN, fmt.Sprintf("%s:*", logGroupARN)},
+		})
+	}
+
+	// FireLens log routers start before the containers they route the logs of, so they are left out.
+	containerNames := inputs.Containers
+	if len(containerNames) == 0 {
+		for containerName, container := range containers {
+			if container.FirelensConfiguration == nil {
+				containerNames = append(containerNames, containerName)
+			}
+		}
+	}
+
+	for _, containerName := range containerNames {
+		container := containers[containerName]
+
+		environment := []TaskDefinitionKeyValuePairInputs{
+			{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://localhost:4317"},
+			{Name: "OTEL_EXPORTER_OTLP_PROTOCOL", Value: "grpc"},
+			{Name: "OTEL_SERVICE_NAME", Value: containerName},
+		}
+
+		if inputs.Traces {
+			environment = append(environment,
+				TaskDefinitionKeyValuePairInputs{Name: "AWS_XRAY_DAEMON_ADDRESS", Value: "localhost:2000"},
+				TaskDefinitionKeyValuePairInputs{Name: "OTEL_PROPAGATORS", Value: "tracecontext,baggage,xray"},
+			)
+		}
+
+		// Variables set on the container take precedence.
+		for _, variable := range environment {
+			isSet := false
+			for _, existing := range container.Environment {
+				if existing.Name == variable.Name {
+					isSet = true
+				}
+			}
+
+			if !isSet {
+				container.Environment = append(container.Environment, variable)
+			}
+		}
+
+		container.DependsOn = append(container.DependsOn, TaskDefinitionContainerDependencyInputs{
+			Condition:     "START",
+			ContainerName: collectorName,
+		})
+		containers[containerName] = container
+	}
+
+	image := inputs.Image
+	if image == "" {
+		image = defaultObservabilityCollectorImage
+	}
+
+	memoryReservation := inputs.MemoryReservation
+	if memoryReservation == 0 {
+		memoryReservation = 64
+	}
+
+	essential := true
+	containers[collectorName] = TaskDefinitionContainerDefinitionInputs{
+		Command:   []string{"--config=env:AOT_CONFIG_CONTENT"},
+		Essential: &essential,
+		Environment: []TaskDefinitionKeyValuePairInputs{
+			{Name: "AOT_CONFIG_CONTENT", Value: config},
+		},
+		Image:             image,
+		MemoryReservation: memoryReservation,
+	}
+
+	return result, nil
+}
+
+// observabilityCollectorConfig renders the collector configuration as JSON, which the collector reads
+// as YAML.
+func observabilityCollectorConfig(inputs *TaskDefinitionObservabilityInputs, collectorName, metricsLogGroup, metricsNamespace string) (string, error) {
+	receivers := map[string]interface{}{
+		"otlp": map[string]interface{}{
+			"protocols": map[string]interface{}{
+				"grpc": map[string]interface{}{"endpoint": "0.0.0.0:4317"},
+				"http": map[string]interface{}{"endpoint": "0.0.0.0:4318"},
+			},
+		},
+	}
+
+	pipelines := map[string]interface{}{}
+
+	if inputs.Traces {
+		receivers["awsxray"] = map[string]interface{}{
+			"endpoint":  "0.0.0.0:2000",
+			"transport": "udp",
+		}
+
+		pipelines["traces"] = map[string]interface{}{
+			"receivers":  []string{"otlp", "awsxray"},
+			"processors": []string{"resourcedetection", "batch/traces"},
+			"exporters":  []string{"awsxray"},
+		}
+	}
+
+	if inputs.Metrics || inputs.Prometheus != nil {
+		var metricsReceivers []string
+		if inputs.Metrics {
+			metricsReceivers = append(metricsReceivers, "otlp")
+		}
+
+		if inputs.Prometheus != nil {
+			metricsPath := inputs.Prometheus.MetricsPath
+			if metricsPath == "" {
+				metricsPath = defaultObservabilityPrometheusPath
+			}
+
+			scrapeInterval := inputs.Prometheus.ScrapeIntervalSeconds
+			if scrapeInterval == 0 {
+				scrapeInterval = defaultObservabilityPrometheusSeconds
+			}
+
+			receivers["prometheus"] = map[string]interface{}{
+				"config": map[string]interface{}{
+					"scrape_configs": []interface{}{
+						map[string]interface{}{
+							"job_name":        collectorName,
+							"metrics_path":    metricsPath,
+							"scrape_interval": fmt.Sprintf("%ds", scrapeInterval),
+							"static_configs": []interface{}{
+								map[string]interface{}{
+									"targets": []string{fmt.Sprintf("localhost:%d", inputs.Prometheus.Port)},
+								},
+							},
+						},
+					},
+				},
+			}
+			metricsReceivers = append(metricsReceivers, "prometheus")
+		}
+
+		pipelines["metrics"] = map[string]interface{}{
+			"receivers":  metricsReceivers,
+			"processors": []string{"resourcedetection", "batch/metrics"},
+			"exporters":  []string{"awsemf"},
+		}
+	}
+
+	config := map[string]interface{}{
+		"receivers": receivers,
+		"processors": map[string]interface{}{
+			"batch/metrics": map[string]interface{}{"timeout": "60s"},
+			"batch/traces":  map[string]interface{}{"timeout": "1s", "send_batch_size": 50},
+			"resourcedetection": map[string]interface{}{
+				"detectors": []string{"env", "ecs"},
+			},
+		},
+		"exporters": map[string]interface{}{
+			"awsemf": map[string]interface{}{
+				"log_group_name": metricsLogGroup,
+				"namespace":      metricsNamespace,
+			},
+			"awsxray": map[string]interface{}{},
+		},
+		"service": map[string]interface{}{
+			"pipelines": pipelines,
+		},
+	}
+
+	result, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// taskRoleObservabilityPolicy allows the task role to send traces to X-Ray and metrics to CloudWatch.
+func taskRoleObservabilityPolicy(ctx *pulumi.Context, name string, observability *taskDefinitionObservabilityResult, taskRole *defaultRoleWithPoliciesResult, opts ...pulumi.ResourceOption) (*iam.RolePolicy, error) {
+	if observability == nil || taskRole == nil || taskRole.Role == nil {
+		return nil, nil
+	}
+
+	policy, err := allowPolicyDocument(ctx, observability.Statements)
+	if err != nil {
+		return nil, err
+	}
+
+	return iam.NewRolePolicy(ctx, fmt.Sprintf("%s-observability", name), &iam.RolePolicyArgs{
+		Role:   taskRole.Role.Name,
+		Policy: pulumi.String(policy),
+	}, append(opts, pulumi.Parent(taskRole.Role))...)
+}
@@ -0,0 +1,276 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/zchase/pulumi-awsx-go/pkg/utils"
+)
+
+const ScheduledTaskIdentifier = "awsx-go:ecs:ScheduledTask"
+
+type ScheduledTaskRetryPolicyInputs struct {
+	MaximumEventAgeInSeconds int `pulumi:"maximumEventAgeInSeconds"`
+	MaximumRetryAttempts     int `pulumi:"maximumRetryAttempts"`
+}
+
+type ScheduledTaskArgs struct {
+	Cluster              pulumi.StringInput                                          `pulumi:"cluster"`
+	DeadLetterQueueArn   string                                                      `pulumi:"deadLetterQueueArn"`
+	Description          string                                                      `pulumi:"description"`
+	Disabled             bool                                                        `pulumi:"disabled"`
+	EnableEcsManagedTags bool                                                        `pulumi:"enableEcsManagedTags"`
+	NetworkConfiguration cloudwatch.EventTargetEcsTargetNetworkConfigurationPtrInput `pulumi:"networkConfiguration"`
+	PlatformVersion      string                                                      `pulumi:"platformVersion"`
+	PropagateTags        string                                                      `pulumi:"propagateTags"`
+	RetryPolicy          *ScheduledTaskRetryPolicyInputs                             `pulumi:"retryPolicy"`
+	Schedule             string                                                      `pulumi:"schedule"`
+	Tags                 map[string]string                                           `pulumi:"tags"`
+	TaskCount            int                                                         `pulumi:"taskCount"`
+	TaskDefinition       string                                                      `pulumi:"taskDefinition"`
+	TaskDefinitionArgs   *FargateTaskDefinitionArgs                                  `pulumi:"taskDefinitionArgs"`
+}
+
+type ScheduledTask struct {
+	pulumi.ResourceState
+
+	Role           *iam.Role               `pulumi:"role"`
+	Rule           *cloudwatch.EventRule   `pulumi:"rule"`
+	Target         *cloudwatch.EventTarget `pulumi:"target"`
+	TaskDefinition *FargateTaskDefinition  `pulumi:"taskDefinition"`
+}
+
+func NewScheduledTask(ctx *pulumi.Context, name string, args *ScheduledTaskArgs, opts ...pulumi.ResourceOption) (*ScheduledTask, error) {
+	if args == nil {
+		args = &ScheduledTaskArgs{}
+	}
+
+	component := &ScheduledTask{}
+	err := ctx.RegisterComponentResource(ScheduledTaskIdentifier, name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, pulumi.Parent(component))
+
+	if args.Cluster == nil {
+		return nil, fmt.Errorf("[cluster] must be specified")
+	}
+
+	if args.Schedule == "" {
+		return nil, fmt.Errorf("[schedule] must be specified")
+	}
+
+	if args.TaskDefinition != "" && args.TaskDefinitionArgs != nil {
+		return nil, fmt.Errorf("Only one of `taskDefinition` or `taskDefinitionArgs` can be provided.")
+	}
+
+	if args.TaskDefinition == "" && args.TaskDefinitionArgs == nil {
+		return nil, fmt.Errorf("Either `taskDefinition` or `taskDefinitionArgs` must be provided.")
+	}
+
+	taskDefinitionARN := pulumi.String(args.TaskDefinition).ToStringOutput()
+	var passedRoleARNs []pulumi.StringOutput
+	if args.TaskDefinitionArgs != nil {
+		taskDefinition, err := NewFargateTaskDefinition(ctx, name, args.TaskDefinitionArgs, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.TaskDefinition = taskDefinition
+		taskDefinitionARN = taskDefinition.TaskDefinition.Arn
+		passedRoleARNs = []pulumi.StringOutput{
+			taskDefinition.TaskDefinition.TaskRoleArn.Elem(),
+			taskDefinition.TaskDefinition.ExecutionRoleArn.Elem(),
+		}
+	}
+
+	if args.NetworkConfiguration == nil {
+		networkConfiguration, err := getDefaultNetworkConfiguration(ctx, name, component)
+		if err != nil {
+			return nil, err
+		}
+
+		args.NetworkConfiguration = &cloudwatch.EventTargetEcsTargetNetworkConfigurationArgs{
+			AssignPublicIp: networkConfiguration.AssignPublicIp,
+			SecurityGroups: networkConfiguration.SecurityGroups,
+			Subnets:        networkConfiguration.Subnets,
+		}
+	}
+
+	role, err := scheduledTaskRole(ctx, name, args.Cluster, taskDefinitionARN, passedRoleARNs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.Role = role
+
+	ruleArgs := &cloudwatch.EventRuleArgs{
+		IsEnabled:          pulumi.BoolPtr(!args.Disabled),
+		ScheduleExpression: pulumi.StringPtr(args.Schedule),
+		Tags:               pulumi.ToStringMap(args.Tags),
+	}
+
+	if args.Description != "" {
+		ruleArgs.Description = pulumi.StringPtr(args.Description)
+	}
+
+	rule, err := cloudwatch.NewEventRule(ctx, name, ruleArgs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.Rule = rule
+
+	taskCount := args.TaskCount
+	if taskCount == 0 {
+		taskCount = 1
+	}
+
+	ecsTarget := &cloudwatch.EventTargetEcsTargetArgs{
+		EnableEcsManagedTags: pulumi.BoolPtr(args.EnableEcsManagedTags),
+		LaunchType:           pulumi.StringPtr("FARGATE"),
+		NetworkConfiguration: args.NetworkConfiguration,
+		Tags:                 pulumi.ToStringMap(args.Tags),
+		TaskCount:            pulumi.IntPtr(taskCount),
+		TaskDefinitionArn:    taskDefinitionARN,
+	}
+
+	if args.PlatformVersion != "" {
+		ecsTarget.PlatformVersion = pulumi.StringPtr(args.PlatformVersion)
+	}
+
+	if args.PropagateTags != "" {
+		ecsTarget.PropagateTags = pulumi.StringPtr(args.PropagateTags)
+	}
+
+	targetArgs := &cloudwatch.EventTargetArgs{
+		Arn:       args.Cluster,
+		EcsTarget: ecsTarget,
+		RoleArn:   role.Arn,
+		Rule:      rule.Name,
+	}
+
+	if args.RetryPolicy != nil {
+		retryPolicy := &cloudwatch.EventTargetRetryPolicyArgs{}
+		if args.RetryPolicy.MaximumEventAgeInSeconds > 0 {
+			retryPolicy.MaximumEventAgeInSeconds = pulumi.IntPtr(args.RetryPolicy.MaximumEventAgeInSeconds)
+		}
+
+		retryPolicy.MaximumRetryAttempts = pulumi.IntPtr(args.RetryPolicy.MaximumRetryAttempts)
+		targetArgs.RetryPolicy = retryPolicy
+	}
+
+	if args.DeadLetterQueueArn != "" {
+		targetArgs.DeadLetterConfig = &cloudwatch.EventTargetDeadLetterConfigArgs{
+			Arn: pulumi.StringPtr(args.DeadLetterQueueArn),
+		}
+	}
+
+	target, err := cloudwatch.NewEventTarget(ctx, name, targetArgs, append(opts, pulumi.Parent(rule))...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.Target = target
+
+	return component, nil
+}
+
+// scheduledTaskRole creates the role EventBridge assumes to run the task. It can run any revision of
+// the task definition's family in the cluster and pass the task and execution roles to ECS. The roles
+// of an existing task definition are not known, so any role can be passed to ECS tasks in that case.
+func scheduledTaskRole(ctx *pulumi.Context, name string, cluster pulumi.StringInput, taskDefinitionARN pulumi.StringOutput, passedRoleARNs []pulumi.StringOutput, opts ...pulumi.ResourceOption) (*iam.Role, error) {
+	assumeRolePolicy, err := serviceAssumeRolePolicy(ctx, "events.amazonaws.com")
+	if err != nil {
+		return nil, err
+	}
+
+	policyInputs := []interface{}{cluster, taskDefinitionARN}
+	for _, roleARN := range passedRoleARNs {
+		policyInputs = append(policyInputs, roleARN)
+	}
+
+	policy := pulumi.All(policyInputs...).ApplyT(func(args []interface{}) (string, error) {
+		clusterARN := args[0].(string)
+
+		parts, err := utils.ParseARN(args[1].(string))
+		if err != nil {
+			return "", err
+		}
+
+		// Strip the revision so that new revisions of the family can be run.
+		family := parts.ResourceID
+		if strings.HasPrefix(parts.ResourceType, "task-definition/") {
+			family = strings.TrimPrefix(parts.ResourceType, "task-definition/")
+		}
+
+		familyARN := fmt.Sprintf("arn:%s:ecs:%s:%s:task-definition/%s:*", parts.Partition, parts.Region, parts.AccountID, family)
+
+		passRole := iam.GetPolicyDocumentStatement{
+			Actions:   []string{"iam:PassRole"},
+			Resources: []string{"*"},
+			Conditions: []iam.GetPolicyDocumentStatementCondition{
+				{
+					Test:     "StringLike",
+					Variable: "iam:PassedToService",
+					Values:   []string{"ecs-tasks.amazonaws.com"},
+				},
+			},
+		}
+
+		if len(args) > 2 {
+			passRole.Resources = nil
+			passRole.Conditions = nil
+			for _, roleARN := range args[2:] {
+				if roleARN.(string) != "" {
+					passRole.Resources = append(passRole.Resources, roleARN.(string))
+				}
+			}
+		}
+
+		statements := []iam.GetPolicyDocumentStatement{
+			{
+				Actions:   []string{"ecs:RunTask"},
+				Resources: []string{familyARN},
+				Conditions: []iam.GetPolicyDocumentStatementCondition{
+					{
+						Test:     "ArnLike",
+						Variable: "ecs:cluster",
+						Values:   []string{clusterARN},
+					},
+				},
+			},
+			{
+				Actions:   []string{"ecs:TagResource"},
+				Resources: []string{"*"},
+				Conditions: []iam.GetPolicyDocumentStatementCondition{
+					{
+						Test:     "StringEquals",
+						Variable: "ecs:CreateAction",
+						Values:   []string{"RunTask"},
+					},
+				},
+			},
+		}
+
+		if len(passRole.Resources) > 0 {
+			statements = append(statements, passRole)
+		}
+
+		return allowPolicyDocument(ctx, statements)
+	}).(pulumi.StringOutput)
+
+	return iam.NewRole(ctx, fmt.Sprintf("%s-events", name), &iam.RoleArgs{
+		AssumeRolePolicy: pulumi.String(assumeRolePolicy.Json),
+		InlinePolicies: iam.RoleInlinePolicyArray{
+			&iam.RoleInlinePolicyArgs{
+				Name:   pulumi.String("run-task"),
+				Policy: policy,
+			},
+		},
+	}, opts...)
+}
@@ -0,0 +1,219 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/zchase/pulumi-awsx-go/pkg/utils"
+)
+
+type taskDefinitionSecretsResult struct {
+	KMSKeyIDs  []string
+	Secrets    []*secretsmanager.Secret
+	ValueFroms []pulumi.StringOutput
+}
+
+// taskDefinitionSecrets creates a Secrets Manager secret for every secret of the containers given a
+// value, and points each secret at the Secrets Manager secret or SSM parameter, with its JSON key and
+// version selectors, it is read from.
+func taskDefinitionSecrets(ctx *pulumi.Context, name string, containers map[string]TaskDefinitionContainerDefinitionInputs, opts ...pulumi.ResourceOption) (*taskDefinitionSecretsResult, error) {
+	result := &taskDefinitionSecretsResult{}
+
+	var containerNames []string
+	for containerName := range containers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	prepareSecrets := func(containerName string, secrets []TaskDefinitionSecretInputs) error {
+		for i := range secrets {
+			secret := &secrets[i]
+
+			if secret.Name == "" {
+				return fmt.Errorf("Container %s: secrets require a [name]", containerName)
+			}
+
+			if secret.Value != nil && secret.ValueFrom != nil {
+				return fmt.Errorf("Container %s: only one of [value] or [valueFrom] can be specified for secret %s", containerName, secret.Name)
+			}
+
+			if secret.Value == nil && secret.ValueFrom == nil {
+				return fmt.Errorf("Container %s: one of [value] or [valueFrom] must be specified for secret %s", containerName, secret.Name)
+			}
+
+			valueFrom := secret.ValueFrom
+			if secret.Value != nil {
+				secretName := fmt.Sprintf("%s-%s-%s", name, containerName, strings.ToLower(secret.Name))
+
+				secretArgs := &secretsmanager.SecretArgs{}
+				if secret.KMSKeyID != "" {
+					secretArgs.KmsKeyId = pulumi.StringPtr(secret.KMSKeyID)
+				}
+
+				createdSecret, err := secretsmanager.NewSecret(ctx, secretName, secretArgs, opts...)
+				if err != nil {
+					return err
+				}
+
+				_, err = secretsmanager.NewSecretVersion(ctx, secretName, &secretsmanager.SecretVersionArgs{
+					SecretId:     createdSecret.ID(),
+					SecretString: pulumi.ToSecret(secret.Value).(pulumi.StringOutput).ToStringPtrOutput(),
+				}, pulumi.Parent(createdSecret))
+				if err != nil {
+					return err
+				}
+
+				result.Secrets = append(result.Secrets, createdSecret)
+				valueFrom = createdSecret.Arn
+			}
+
+			if secret.KMSKeyID != "" {
+				result.KMSKeyIDs = append(result.KMSKeyIDs, secret.KMSKeyID)
+			}
+
+			resolvedValueFrom := valueFrom.ToStringOutput()
+			if secret.JSONKey != "" || secret.VersionStage != "" || secret.VersionID != "" {
+				secretName := secret.Name
+				jsonKey, versionStage, versionID := secret.JSONKey, secret.VersionStage, secret.VersionID
+				resolvedValueFrom = resolvedValueFrom.ApplyT(func(valueFrom string) (string, error) {
+					// The selectors of a secret that already has some are replaced.
+					parts, err := utils.ParseARN(secretsManagerSecretARN(valueFrom))
+					if err != nil || parts.Service != "secretsmanager" {
+						return "", fmt.Errorf("Secret %s: [jsonKey], [versionStage] and [versionId] can only select from Secrets Manager secrets", secretName)
+					}
+
+					return fmt.Sprintf("%s:%s:%s:%s", secretsManagerSecretARN(valueFrom), jsonKey, versionStage, versionID), nil
+				}).(pulumi.StringOutput)
+			}
+
+			secret.ValueFrom = resolvedValueFrom
+			result.ValueFroms = append(result.ValueFroms, resolvedValueFrom)
+		}
+
+		return nil
+	}
+
+	for _, containerName := range containerNames {
+		container := containers[containerName]
+
+		err := prepareSecrets(containerName, container.Secrets)
+		if err != nil {
+			return nil, err
+		}
+
+		if container.LogConfiguration != nil {
+			err = prepareSecrets(containerName, container.LogConfiguration.SecretOptions)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// secretsManagerSecretARN strips the JSON key and version selectors from a Secrets Manager secret ARN.
+func secretsManagerSecretARN(valueFrom string) string {
+	parts := strings.Split(valueFrom, ":")
+	if len(parts) > 7 {
+		parts = parts[:7]
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// executionRoleSecretsPolicy grants the execution role read access to exactly the secrets and
+// parameters the containers reference, and decrypt access to their customer managed KMS keys.
+func executionRoleSecretsPolicy(ctx *pulumi.Context, name string, secrets *taskDefinitionSecretsResult, executionRole *defaultRoleWithPoliciesResult, opts ...pulumi.ResourceOption) (*iam.RolePolicy, error) {
+	if len(secrets.ValueFroms) == 0 || executionRole == nil || executionRole.Role == nil {
+		return nil, nil
+	}
+
+	var valueFroms []interface{}
+	for _, valueFrom := range secrets.ValueFroms {
+		valueFroms = append(valueFroms, valueFrom)
+	}
+
+	kmsKeyIDs := secrets.KMSKeyIDs
+
+	prefix, err := arnPrefix(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := pulumi.All(valueFroms...).ApplyT(func(values []interface{}) (string, error) {
+		var secretARNs []string
+		var parameterARNs []string
+		for _, value := range values {
+			valueFrom := value.(string)
+
+			// Secrets Manager ARNs with JSON key and version selectors have more parts than an ARN.
+			parts, err := utils.ParseARN(secretsManagerSecretARN(valueFrom))
+			if err == nil && parts.Service == "secretsmanager" {
+				secretARNs = appendUnique(secretARNs, secretsManagerSecretARN(valueFrom))
+				continue
+			}
+
+			if err == nil && parts.Service == "ssm" {
+				parameterARNs = appendUnique(parameterARNs, valueFrom)
+				continue
+			}
+
+			// Parameters in the region of the task can be referenced by name.
+			parameterARNs = appendUnique(parameterARNs, fmt.Sprintf("%s:parameter/%s", prefix.For("ssm"), strings.TrimPrefix(valueFrom, "/")))
+		}
+
+		var keyARNs []string
+		for _, kmsKeyID := range kmsKeyIDs {
+			if strings.HasPrefix(kmsKeyID, "arn:") {
+				keyARNs = appendUnique(keyARNs, kmsKeyID)
+				continue
+			}
+
+			keyARNs = appendUnique(keyARNs, fmt.Sprintf("%s:key/%s", prefix.For("kms"), kmsKeyID))
+		}
+
+		var statements []iam.GetPolicyDocumentStatement
+		if len(secretARNs) > 0 {
+			statements = append(statements, iam.GetPolicyDocumentStatement{
+				Actions:   []string{"secretsmanager:GetSecretValue"},
+				Resources: secretARNs,
+			})
+		}
+
+		if len(parameterARNs) > 0 {
+			statements = append(statements, iam.GetPolicyDocumentStatement{
+				Actions:   []string{"ssm:GetParameters"},
+				Resources: parameterARNs,
+			})
+		}
+
+		if len(keyARNs) > 0 {
+			statements = append(statements, iam.GetPolicyDocumentStatement{
+				Actions:   []string{"kms:Decrypt"},
+				Resources: keyARNs,
+			})
+		}
+
+		return allowPolicyDocument(ctx, statements)
+	}).(pulumi.StringOutput)
+
+	return iam.NewRolePolicy(ctx, fmt.Sprintf("%s-secrets", name), &iam.RolePolicyArgs{
+		Role:   executionRole.Role.Name,
+		Policy: policy,
+	}, append(opts, pulumi.Parent(executionRole.Role))...)
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
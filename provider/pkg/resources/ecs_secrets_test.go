@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestSecretsManagerSecretARN(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-west-2:123456789012:secret:db-AbCdEf"
+
+	cases := map[string]string{
+		"secret":          secretARN,
+		"JSON key":        secretARN + ":password::",
+		"version stage":   secretARN + ":password:AWSCURRENT:",
+		"version ID":      secretARN + ":::0123456789abcdef",
+		"all selectors":   secretARN + ":password:AWSPREVIOUS:0123456789abcdef",
+		"trailing colons": secretARN + ":::",
+	}
+
+	for name, valueFrom := range cases {
+		if arn := secretsManagerSecretARN(valueFrom); arn != secretARN {
+			t.Errorf("%s: expected %s to be secret %s, got %s", name, valueFrom, secretARN, arn)
+		}
+	}
+}
+
+func TestExecutionRoleSecretsPolicy(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-west-2:123456789012:secret:db-AbCdEf"
+	var policy string
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		containers := map[string]TaskDefinitionContainerDefinitionInputs{
+			"app": {
+				Secrets: []TaskDefinitionSecretInputs{
+					{Name: "PASSWORD", ValueFrom: pulumi.String(secretARN), JSONKey: "password"},
+					{Name: "USERNAME", ValueFrom: pulumi.String(secretARN + ":username:AWSCURRENT:"), VersionStage: "AWSPREVIOUS"},
+					{Name: "TOKEN", ValueFrom: pulumi.String(secretARN + ":token::")},
+					{Name: "API_KEY", ValueFrom: pulumi.String("/app/api-key")},
+				},
+			},
+		}
+
+		secrets, err := taskDefinitionSecrets(ctx, "task", containers)
+		if err != nil {
+			return err
+		}
+
+		role, err := iam.NewRole(ctx, "task-execution", &iam.RoleArgs{
+			AssumeRolePolicy: pulumi.String("{}"),
+		})
+		if err != nil {
+			return err
+		}
+
+		rolePolicy, err := executionRoleSecretsPolicy(ctx, "task-execution", secrets, &defaultRoleWithPoliciesResult{Role: role})
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		rolePolicy.Policy.ApplyT(func(document string) string {
+			defer wg.Done()
+			policy = document
+			return document
+		})
+		wg.Wait()
+
+		return nil
+	}, pulumi.WithMocks("project", "stack", containerDefinitionMocks{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(policy, "secretsmanager:GetSecretValue") || !strings.Contains(policy, `"`+secretARN+`"`) {
+		t.Errorf("expected GetSecretValue on %s, got %s", secretARN, policy)
+	}
+
+	if strings.Contains(policy, "parameter/arn:") || strings.Contains(policy, secretARN+":") {
+		t.Errorf("expected the selectors to be stripped from the secret, got %s", policy)
+	}
+
+	if !strings.Contains(policy, "arn:aws:ssm:us-west-2:123456789012:parameter/app/api-key") {
+		t.Errorf("expected GetParameters on the parameter, got %s", policy)
+	}
+}
@@ -0,0 +1,400 @@
+package resources
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/appautoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/zchase/pulumi-awsx-go/pkg/utils"
+)
+
+type ServiceAutoScalingTargetTrackingInputs struct {
+	DisableScaleIn   bool    `pulumi:"disableScaleIn"`
+	ScaleInCooldown  int     `pulumi:"scaleInCooldown"`
+	ScaleOutCooldown int     `pulumi:"scaleOutCooldown"`
+	TargetValue      float64 `pulumi:"targetValue"`
+}
+
+type ServiceAutoScalingRequestCountInputs struct {
+	DisableScaleIn   bool               `pulumi:"disableScaleIn"`
+	LoadBalancerArn  pulumi.StringInput `pulumi:"loadBalancerArn"`
+	ScaleInCooldown  int                `pulumi:"scaleInCooldown"`
+	ScaleOutCooldown int                `pulumi:"scaleOutCooldown"`
+	TargetGroupArn   pulumi.StringInput `pulumi:"targetGroupArn"`
+	TargetValue      float64            `pulumi:"targetValue"`
+}
+
+type ServiceAutoScalingStepAdjustmentInputs struct {
+	MetricIntervalLowerBound *float64 `pulumi:"metricIntervalLowerBound"`
+	MetricIntervalUpperBound *float64 `pulumi:"metricIntervalUpperBound"`
+	ScalingAdjustment        int      `pulumi:"scalingAdjustment"`
+}
+
+type ServiceAutoScalingStepScalingInputs struct {
+	AdjustmentType        string                                   `pulumi:"adjustmentType"`
+	ComparisonOperator    string                                   `pulumi:"comparisonOperator"`
+	Cooldown              int                                      `pulumi:"cooldown"`
+	Dimensions            map[string]string                        `pulumi:"dimensions"`
+	EvaluationPeriods     int                                      `pulumi:"evaluationPeriods"`
+	MetricAggregationType string                                   `pulumi:"metricAggregationType"`
+	MetricName            string                                   `pulumi:"metricName"`
+	Name                  string                                   `pulumi:"name"`
+	Namespace             string                                   `pulumi:"namespace"`
+	Period                int                                      `pulumi:"period"`
+	Statistic             string                                   `pulumi:"statistic"`
+	StepAdjustments       []ServiceAutoScalingStepAdjustmentInputs `pulumi:"stepAdjustments"`
+	Threshold             float64                                  `pulumi:"threshold"`
+}
+
+type ServiceAutoScalingScheduledActionInputs struct {
+	EndTime     string `pulumi:"endTime"`
+	MaxCapacity *int   `pulumi:"maxCapacity"`
+	MinCapacity *int   `pulumi:"minCapacity"`
+	Name        string `pulumi:"name"`
+	Schedule    string `pulumi:"schedule"`
+	StartTime   string `pulumi:"startTime"`
+	Timezone    string `pulumi:"timezone"`
+}
+
+type ServiceAutoScalingInputs struct {
+	CPUUtilization        *ServiceAutoScalingTargetTrackingInputs   `pulumi:"cpuUtilization"`
+	MaxCapacity           int                                       `pulumi:"maxCapacity"`
+	MemoryUtilization     *ServiceAutoScalingTargetTrackingInputs   `pulumi:"memoryUtilization"`
+	MinCapacity           int                                       `pulumi:"minCapacity"`
+	RequestCountPerTarget *ServiceAutoScalingRequestCountInputs     `pulumi:"requestCountPerTarget"`
+	ScheduledActions      []ServiceAutoScalingScheduledActionInputs `pulumi:"scheduledActions"`
+	StepScaling           []ServiceAutoScalingStepScalingInputs     `pulumi:"stepScaling"`
+}
+
+type ServiceAutoScaling struct {
+	Alarms           []*cloudwatch.MetricAlarm
+	Policies         []*appautoscaling.Policy
+	ScheduledActions []*appautoscaling.ScheduledAction
+	Target           *appautoscaling.Target
+}
+
+func (s *ServiceAutoScalingInputs) Validate() error {
+	if s.MaxCapacity == 0 {
+		return fmt.Errorf("[autoScaling] [maxCapacity] must be specified")
+	}
+
+	if s.MinCapacity > s.MaxCapacity {
+		return fmt.Errorf("[autoScaling] [minCapacity] can not be greater than [maxCapacity]")
+	}
+
+	if s.RequestCountPerTarget != nil && s.RequestCountPerTarget.LoadBalancerArn == nil {
+		return fmt.Errorf("[autoScaling] [requestCountPerTarget] [loadBalancerArn] must be specified")
+	}
+
+	for _, stepScaling := range s.StepScaling {
+		if stepScaling.Name == "" || stepScaling.MetricName == "" || stepScaling.Namespace == "" {
+			return fmt.Errorf("[autoScaling] [stepScaling] requires a [name], [metricName] and [namespace]")
+		}
+
+		if len(stepScaling.StepAdjustments) == 0 {
+			return fmt.Errorf("[autoScaling] [stepScaling] %s requires at least one of [stepAdjustments]", stepScaling.Name)
+		}
+	}
+
+	for _, scheduledAction := range s.ScheduledActions {
+		if scheduledAction.Name == "" || scheduledAction.Schedule == "" {
+			return fmt.Errorf("[autoScaling] [scheduledActions] require a [name] and [schedule]")
+		}
+
+		if scheduledAction.MinCapacity == nil && scheduledAction.MaxCapacity == nil {
+			return fmt.Errorf("[autoScaling] [scheduledActions] %s requires at least one of [minCapacity] or [maxCapacity]", scheduledAction.Name)
+		}
+
+		if scheduledAction.MinCapacity != nil && scheduledAction.MaxCapacity != nil && *scheduledAction.MinCapacity > *scheduledAction.MaxCapacity {
+			return fmt.Errorf("[autoScaling] [scheduledActions] %s [minCapacity] can not be greater than [maxCapacity]", scheduledAction.Name)
+		}
+	}
+
+	return nil
+}
+
+// InitialDesiredCount returns the number of tasks a service with auto scaling starts with. Once the
+// service exists, the desired count is owned by Application Auto Scaling.
+func (s *ServiceAutoScalingInputs) InitialDesiredCount(desiredCount int) int {
+	if desiredCount < s.MinCapacity {
+		return s.MinCapacity
+	}
+
+	if desiredCount > s.MaxCapacity {
+		return s.MaxCapacity
+	}
+
+	return desiredCount
+}
+
+// serviceAutoScaling registers the service as a scalable target and attaches the configured target
+// tracking and step scaling policies and scheduled actions to it.
+func serviceAutoScaling(ctx *pulumi.Context, name string, inputs *ServiceAutoScalingInputs, service *ecs.Service, opts ...pulumi.ResourceOption) (*ServiceAutoScaling, error) {
+	resourceID := pulumi.All(service.Cluster, service.Name).ApplyT(func(args []interface{}) (string, error) {
+		clusterName := args[0].(string)
+		serviceName := args[1].(string)
+
+		// The cluster of a service is usually its ARN, but can be a plain name for the default cluster.
+		parts, err := utils.ParseARN(clusterName)
+		if err == nil {
+			clusterName = parts.ResourceID
+		}
+
+		return fmt.Sprintf("service/%s/%s", clusterName, serviceName), nil
+	}).(pulumi.StringOutput)
+
+	target, err := appautoscaling.NewTarget(ctx, name, &appautoscaling.TargetArgs{
+		MaxCapacity:       pulumi.Int(inputs.MaxCapacity),
+		MinCapacity:       pulumi.Int(inputs.MinCapacity),
+		ResourceId:        resourceID,
+		ScalableDimension: pulumi.String("ecs:service:DesiredCount"),
+		ServiceNamespace:  pulumi.String("ecs"),
+	}, append(opts, pulumi.DependsOn([]pulumi.Resource{service}))...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ServiceAutoScaling{
+		Target: target,
+	}
+
+	policyOpts := append(opts, pulumi.Parent(target), pulumi.DependsOn([]pulumi.Resource{target}))
+
+	addTargetTrackingPolicy := func(policyName string, inputs ServiceAutoScalingTargetTrackingInputs, metric *appautoscaling.PolicyTargetTrackingScalingPolicyConfigurationPredefinedMetricSpecificationArgs) error {
+		configuration := &appautoscaling.PolicyTargetTrackingScalingPolicyConfigurationArgs{
+			DisableScaleIn:                pulumi.BoolPtr(inputs.DisableScaleIn),
+			PredefinedMetricSpecification: metric,
+			TargetValue:                   pulumi.Float64(inputs.TargetValue),
+		}
+
+		if inputs.ScaleInCooldown > 0 {
+			configuration.ScaleInCooldown = pulumi.IntPtr(inputs.ScaleInCooldown)
+		}
+
+		if inputs.ScaleOutCooldown > 0 {
+			configuration.ScaleOutCooldown = pulumi.IntPtr(inputs.ScaleOutCooldown)
+		}
+
+		policy, err := appautoscaling.NewPolicy(ctx, policyName, &appautoscaling.PolicyArgs{
+			PolicyType:                               pulumi.String("TargetTrackingScaling"),
+			ResourceId:                               target.ResourceId,
+			ScalableDimension:                        target.ScalableDimension,
+			ServiceNamespace:                         target.ServiceNamespace,
+			TargetTrackingScalingPolicyConfiguration: configuration,
+		}, policyOpts...)
+		if err != nil {
+			return err
+		}
+
+		result.Policies = append(result.Policies, policy)
+		return nil
+	}
+
+	if inputs.CPUUtilization != nil {
+		err = addTargetTrackingPolicy(fmt.Sprintf("%s-cpu", name), *inputs.CPUUtilization, &appautoscaling.PolicyTargetTrackingScalingPolicyConfigurationPredefinedMetricSpecificationArgs{
+			PredefinedMetricType: pulumi.String("ECSServiceAverageCPUUtilization"),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if inputs.MemoryUtilization != nil {
+		err = addTargetTrackingPolicy(fmt.Sprintf("%s-memory", name), *inputs.MemoryUtilization, &appautoscaling.PolicyTargetTrackingScalingPolicyConfigurationPredefinedMetricSpecificationArgs{
+			PredefinedMetricType: pulumi.String("ECSServiceAverageMemoryUtilization"),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if requestCount := inputs.RequestCountPerTarget; requestCount != nil {
+		// The first target group the service is registered with is used unless one is given.
+		targetGroupArn := service.LoadBalancers.Index(pulumi.Int(0)).TargetGroupArn().Elem()
+		if requestCount.TargetGroupArn != nil {
+			targetGroupArn = requestCount.TargetGroupArn.ToStringOutput()
+		}
+
+		err = addTargetTrackingPolicy(fmt.Sprintf("%s-requests", name), ServiceAutoScalingTargetTrackingInputs{
+			DisableScaleIn:   requestCount.DisableScaleIn,
+			ScaleInCooldown:  requestCount.ScaleInCooldown,
+			ScaleOutCooldown: requestCount.ScaleOutCooldown,
+			TargetValue:      requestCount.TargetValue,
+		}, &appautoscaling.PolicyTargetTrackingScalingPolicyConfigurationPredefinedMetricSpecificationArgs{
+			PredefinedMetricType: pulumi.String("ALBRequestCountPerTarget"),
+			ResourceLabel:        pulumi.All(requestCount.LoadBalancerArn, targetGroupArn).ApplyT(albRequestCountResourceLabel).(pulumi.StringOutput),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, stepScaling := range inputs.StepScaling {
+		policy, alarm, err := serviceStepScalingPolicy(ctx, fmt.Sprintf("%s-%s", name, stepScaling.Name), stepScaling, target, policyOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Policies = append(result.Policies, policy)
+		result.Alarms = append(result.Alarms, alarm)
+	}
+
+	for _, scheduledActionIn := range inputs.ScheduledActions {
+		scheduledActionArgs := &appautoscaling.ScheduledActionArgs{
+			Name:              pulumi.StringPtr(scheduledActionIn.Name),
+			ResourceId:        target.ResourceId,
+			ScalableDimension: target.ScalableDimension,
+			ServiceNamespace:  target.ServiceNamespace,
+			Schedule:          pulumi.String(scheduledActionIn.Schedule),
+		}
+
+		// A capacity left out of the action keeps its current value.
+		scalableTargetAction := &appautoscaling.ScheduledActionScalableTargetActionArgs{}
+		if scheduledActionIn.MaxCapacity != nil {
+			scalableTargetAction.MaxCapacity = pulumi.IntPtr(*scheduledActionIn.MaxCapacity)
+		}
+
+		if scheduledActionIn.MinCapacity != nil {
+			scalableTargetAction.MinCapacity = pulumi.IntPtr(*scheduledActionIn.MinCapacity)
+		}
+
+		scheduledActionArgs.ScalableTargetAction = scalableTargetAction
+
+		if scheduledActionIn.StartTime != "" {
+			scheduledActionArgs.StartTime = pulumi.StringPtr(scheduledActionIn.StartTime)
+		}
+
+		if scheduledActionIn.EndTime != "" {
+			scheduledActionArgs.EndTime = pulumi.StringPtr(scheduledActionIn.EndTime)
+		}
+
+		if scheduledActionIn.Timezone != "" {
+			scheduledActionArgs.Timezone = pulumi.StringPtr(scheduledActionIn.Timezone)
+		}
+
+		scheduledAction, err := appautoscaling.NewScheduledAction(ctx, fmt.Sprintf("%s-%s", name, scheduledActionIn.Name), scheduledActionArgs, policyOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.ScheduledActions = append(result.ScheduledActions, scheduledAction)
+	}
+
+	return result, nil
+}
+
+// serviceStepScalingPolicy creates a step scaling policy along with the CloudWatch alarm on a custom
+// metric that triggers it.
+func serviceStepScalingPolicy(ctx *pulumi.Context, name string, inputs ServiceAutoScalingStepScalingInputs, target *appautoscaling.Target, opts ...pulumi.ResourceOption) (*appautoscaling.Policy, *cloudwatch.MetricAlarm, error) {
+	adjustmentType := inputs.AdjustmentType
+	if adjustmentType == "" {
+		adjustmentType = "ChangeInCapacity"
+	}
+
+	metricAggregationType := inputs.MetricAggregationType
+	if metricAggregationType == "" {
+		metricAggregationType = "Average"
+	}
+
+	var stepAdjustments appautoscaling.PolicyStepScalingPolicyConfigurationStepAdjustmentArray
+	for _, step := range inputs.StepAdjustments {
+		stepAdjustment := &appautoscaling.PolicyStepScalingPolicyConfigurationStepAdjustmentArgs{
+			ScalingAdjustment: pulumi.Int(step.ScalingAdjustment),
+		}
+
+		if step.MetricIntervalLowerBound != nil {
+			stepAdjustment.MetricIntervalLowerBound = pulumi.StringPtr(strconv.FormatFloat(*step.MetricIntervalLowerBound, 'f', -1, 64))
+		}
+
+		if step.MetricIntervalUpperBound != nil {
+			stepAdjustment.MetricIntervalUpperBound = pulumi.StringPtr(strconv.FormatFloat(*step.MetricIntervalUpperBound, 'f', -1, 64))
+		}
+
+		stepAdjustments = append(stepAdjustments, stepAdjustment)
+	}
+
+	stepScalingConfiguration := &appautoscaling.PolicyStepScalingPolicyConfigurationArgs{
+		AdjustmentType:        pulumi.StringPtr(adjustmentType),
+		MetricAggregationType: pulumi.StringPtr(metricAggregationType),
+		StepAdjustments:       stepAdjustments,
+	}
+
+	if inputs.Cooldown > 0 {
+		stepScalingConfiguration.Cooldown = pulumi.IntPtr(inputs.Cooldown)
+	}
+
+	policy, err := appautoscaling.NewPolicy(ctx, name, &appautoscaling.PolicyArgs{
+		PolicyType:                     pulumi.String("StepScaling"),
+		ResourceId:                     target.ResourceId,
+		ScalableDimension:              target.ScalableDimension,
+		ServiceNamespace:               target.ServiceNamespace,
+		StepScalingPolicyConfiguration: stepScalingConfiguration,
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comparisonOperator := inputs.ComparisonOperator
+	if comparisonOperator == "" {
+		comparisonOperator = "GreaterThanOrEqualToThreshold"
+	}
+
+	evaluationPeriods := inputs.EvaluationPeriods
+	if evaluationPeriods == 0 {
+		evaluationPeriods = 1
+	}
+
+	period := inputs.Period
+	if period == 0 {
+		period = 60
+	}
+
+	statistic := inputs.Statistic
+	if statistic == "" {
+		statistic = "Average"
+	}
+
+	alarm, err := cloudwatch.NewMetricAlarm(ctx, name, &cloudwatch.MetricAlarmArgs{
+		AlarmActions:       pulumi.Array{policy.Arn},
+		ComparisonOperator: pulumi.String(comparisonOperator),
+		Dimensions:         pulumi.ToStringMap(inputs.Dimensions),
+		EvaluationPeriods:  pulumi.Int(evaluationPeriods),
+		MetricName:         pulumi.StringPtr(inputs.MetricName),
+		Namespace:          pulumi.StringPtr(inputs.Namespace),
+		Period:             pulumi.IntPtr(period),
+		Statistic:          pulumi.StringPtr(statistic),
+		Threshold:          pulumi.Float64Ptr(inputs.Threshold),
+	}, append(opts, pulumi.Parent(policy))...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return policy, alarm, nil
+}
+
+// albRequestCountResourceLabel builds the resource label identifying the target group of an
+// Application Load Balancer, in the form app/<lb-name>/<lb-id>/targetgroup/<tg-name>/<tg-id>.
+func albRequestCountResourceLabel(args []interface{}) (string, error) {
+	if args[1].(string) == "" {
+		return "", fmt.Errorf("[requestCountPerTarget] requires a target group attached through [loadBalancers] or a [targetGroupArn]")
+	}
+
+	loadBalancerARN, err := utils.ParseARN(args[0].(string))
+	if err != nil {
+		return "", err
+	}
+
+	targetGroupARN, err := utils.ParseARN(args[1].(string))
+	if err != nil {
+		return "", err
+	}
+
+	if loadBalancerARN.ResourceType != "loadbalancer" || targetGroupARN.ResourceType != "targetgroup" {
+		return "", fmt.Errorf("[requestCountPerTarget] requires the ARNs of an Application Load Balancer and a target group")
+	}
+
+	return fmt.Sprintf("%s/targetgroup/%s", loadBalancerARN.ResourceID, targetGroupARN.ResourceID), nil
+}
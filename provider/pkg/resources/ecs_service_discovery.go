@@ -0,0 +1,222 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/servicediscovery"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type ServiceDiscoveryNamespaceInputs struct {
+	Description string             `pulumi:"description"`
+	Name        string             `pulumi:"name"`
+	VpcID       pulumi.StringInput `pulumi:"vpcId"`
+}
+
+type ServiceDiscoveryInputs struct {
+	ContainerName    string                           `pulumi:"containerName"`
+	ContainerPort    int                              `pulumi:"containerPort"`
+	DNSRecordType    string                           `pulumi:"dnsRecordType"`
+	DNSTTL           int                              `pulumi:"dnsTtl"`
+	FailureThreshold int                              `pulumi:"failureThreshold"`
+	Name             string                           `pulumi:"name"`
+	Namespace        *ServiceDiscoveryNamespaceInputs `pulumi:"namespace"`
+	NamespaceID      pulumi.StringInput               `pulumi:"namespaceId"`
+	PortName         string                           `pulumi:"portName"`
+}
+
+type serviceDiscoveryResult struct {
+	Namespace         *servicediscovery.PrivateDnsNamespace
+	Service           *servicediscovery.Service
+	ServiceRegistries ecs.ServiceServiceRegistriesPtrInput
+}
+
+// serviceDiscovery registers the tasks of a service with a Cloud Map service in a private DNS namespace,
+// created in the given VPC, or the default VPC, unless an existing namespace is given. Tasks are
+// registered with A records in the awsvpc network mode, and with SRV records for the port of a
+// container, given directly or by the name of a port mapping, otherwise. ECS reports the health of the
+// tasks to Cloud Map.
+func serviceDiscovery(ctx *pulumi.Context, name string, inputs *ServiceDiscoveryInputs, serviceRegistries ecs.ServiceServiceRegistriesPtrInput, networkMode string, containers map[string]TaskDefinitionContainerDefinitionInputs, parent pulumi.Resource, opts ...pulumi.ResourceOption) (*serviceDiscoveryResult, error) {
+	if inputs == nil {
+		return &serviceDiscoveryResult{ServiceRegistries: serviceRegistries}, nil
+	}
+
+	if serviceRegistries != nil {
+		return nil, fmt.Errorf("Only one of `serviceDiscovery` or `serviceRegistries` can be provided.")
+	}
+
+	if inputs.Namespace != nil && inputs.NamespaceID != nil {
+		return nil, fmt.Errorf("Only one of [namespace] or [namespaceId] can be specified for the [serviceDiscovery]")
+	}
+
+	if inputs.Namespace == nil && inputs.NamespaceID == nil {
+		return nil, fmt.Errorf("One of [namespace] or [namespaceId] must be specified for the [serviceDiscovery]")
+	}
+
+	containerName, containerPort, err := serviceDiscoveryPort(inputs, containers)
+	if err != nil {
+		return nil, err
+	}
+
+	recordType := inputs.DNSRecordType
+	if recordType == "" {
+		recordType = "A"
+		if containerName != "" {
+			recordType = "SRV"
+		}
+	}
+
+	switch recordType {
+	case "A":
+		if networkMode != "" && networkMode != "awsvpc" {
+			return nil, fmt.Errorf("[serviceDiscovery] A records require the awsvpc network mode, register a container port with SRV records instead")
+		}
+	case "SRV":
+		if containerName == "" {
+			return nil, fmt.Errorf("[serviceDiscovery] SRV records require a [portName] or a [containerName] and [containerPort]")
+		}
+	default:
+		return nil, fmt.Errorf("[serviceDiscovery] [dnsRecordType] must be A or SRV")
+	}
+
+	result := &serviceDiscoveryResult{}
+
+	namespaceID := inputs.NamespaceID
+	if inputs.Namespace != nil {
+		if inputs.Namespace.Name == "" {
+			return nil, fmt.Errorf("[serviceDiscovery] [namespace] requires a [name]")
+		}
+
+		vpcID := inputs.Namespace.VpcID
+		if vpcID == nil {
+			defaultVPC, err := getDefaultVPC(ctx, nil, pulumi.Parent(parent))
+			if err != nil {
+				return nil, err
+			}
+
+			vpcID = defaultVPC.VPCID
+		}
+
+		namespace, err := servicediscovery.NewPrivateDnsNamespace(ctx, name, &servicediscovery.PrivateDnsNamespaceArgs{
+			Description: pulumi.StringPtr(inputs.Namespace.Description),
+			Name:        pulumi.StringPtr(inputs.Namespace.Name),
+			Vpc:         vpcID,
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Namespace = namespace
+		namespaceID = namespace.ID().ToStringOutput()
+	}
+
+	serviceName := inputs.Name
+	if serviceName == "" {
+		serviceName = name
+	}
+
+	ttl := inputs.DNSTTL
+	if ttl == 0 {
+		ttl = 10
+	}
+
+	failureThreshold := inputs.FailureThreshold
+	if failureThreshold == 0 {
+		failureThreshold = 1
+	}
+
+	service, err := servicediscovery.NewService(ctx, name, &servicediscovery.ServiceArgs{
+		DnsConfig: &servicediscovery.ServiceDnsConfigArgs{
+			DnsRecords: servicediscovery.ServiceDnsConfigDnsRecordArray{
+				&servicediscovery.ServiceDnsConfigDnsRecordArgs{
+					Ttl:  pulumi.Int(ttl),
+					Type: pulumi.String(recordType),
+				},
+			},
+			NamespaceId:   namespaceID,
+			RoutingPolicy: pulumi.StringPtr("MULTIVALUE"),
+		},
+		// Tasks are deregistered by ECS, which would otherwise be blocked by the instances left behind.
+		ForceDestroy: pulumi.BoolPtr(true),
+		HealthCheckCustomConfig: &servicediscovery.ServiceHealthCheckCustomConfigArgs{
+			FailureThreshold: pulumi.IntPtr(failureThreshold),
+		},
+		Name: pulumi.StringPtr(serviceName),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Service = service
+
+	registries := &ecs.ServiceServiceRegistriesArgs{
+		RegistryArn: service.Arn,
+	}
+
+	if recordType == "SRV" {
+		registries.ContainerName = pulumi.StringPtr(containerName)
+		registries.ContainerPort = containerPort
+	}
+
+	result.ServiceRegistries = registries
+
+	return result, nil
+}
+
+// serviceDiscoveryPort returns the container and port registered with SRV records, looking up the port
+// mapping of the task definition containers named [portName].
+func serviceDiscoveryPort(inputs *ServiceDiscoveryInputs, containers map[string]TaskDefinitionContainerDefinitionInputs) (string, pulumi.IntPtrInput, error) {
+	if inputs.PortName == "" {
+		if (inputs.ContainerName == "") != (inputs.ContainerPort == 0) {
+			return "", nil, fmt.Errorf("[serviceDiscovery] [containerName] and [containerPort] must be specified together")
+		}
+
+		if inputs.ContainerName == "" {
+			return "", nil, nil
+		}
+
+		return inputs.ContainerName, pulumi.IntPtr(inputs.ContainerPort), nil
+	}
+
+	if inputs.ContainerPort != 0 {
+		return "", nil, fmt.Errorf("Only one of [portName] or [containerPort] can be specified for the [serviceDiscovery]")
+	}
+
+	if containers == nil {
+		return "", nil, fmt.Errorf("[serviceDiscovery] [portName] requires the task definition to be created by the service")
+	}
+
+	var containerNames []string
+	for containerName := range containers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	for _, containerName := range containerNames {
+		if inputs.ContainerName != "" && inputs.ContainerName != containerName {
+			continue
+		}
+
+		for _, mapping := range containers[containerName].PortMappings {
+			if mapping.Name != inputs.PortName {
+				continue
+			}
+
+			// The ports of a mapping default to the port of its target group.
+			containerPort := mapping.ContainerPort
+			if containerPort == nil && mapping.TargetGroup != nil {
+				containerPort = mapping.TargetGroup.Port
+			}
+
+			if containerPort == nil {
+				containerPort = mapping.HostPort
+			}
+
+			return containerName, containerPort, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("[serviceDiscovery] no container has a port mapping named %s", inputs.PortName)
+}
@@ -0,0 +1,145 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestServiceDiscoveryPort(t *testing.T) {
+	containers := map[string]TaskDefinitionContainerDefinitionInputs{
+		"app": {
+			PortMappings: []TaskDefinitionPortMappingInputs{
+				{Name: "http", ContainerPort: pulumi.IntPtr(8080)},
+			},
+		},
+		"sidecar": {
+			PortMappings: []TaskDefinitionPortMappingInputs{
+				{Name: "admin", HostPort: pulumi.IntPtr(9901)},
+				{Name: "http", ContainerPort: pulumi.IntPtr(15000)},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		inputs        ServiceDiscoveryInputs
+		containerName string
+		containerPort *int
+	}{
+		"without a port": {},
+		"container and port": {
+			inputs:        ServiceDiscoveryInputs{ContainerName: "app", ContainerPort: 80},
+			containerName: "app",
+			containerPort: intRef(80),
+		},
+		"port name": {
+			inputs:        ServiceDiscoveryInputs{PortName: "admin"},
+			containerName: "sidecar",
+			containerPort: intRef(9901),
+		},
+		"port name of the first container": {
+			inputs:        ServiceDiscoveryInputs{PortName: "http"},
+			containerName: "app",
+			containerPort: intRef(8080),
+		},
+		"port name of a given container": {
+			inputs:        ServiceDiscoveryInputs{ContainerName: "sidecar", PortName: "http"},
+			containerName: "sidecar",
+			containerPort: intRef(15000),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			containerName, containerPort, err := serviceDiscoveryPort(&c.inputs, containers)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if containerName != c.containerName {
+				t.Errorf("expected container %q, got %q", c.containerName, containerName)
+			}
+
+			actual := knownPort(containerPort)
+			if (actual == nil) != (c.containerPort == nil) || (actual != nil && *actual != *c.containerPort) {
+				t.Errorf("expected port %v, got %v", c.containerPort, actual)
+			}
+		})
+	}
+}
+
+func TestServiceDiscoveryPortFromSingleContainer(t *testing.T) {
+	// A service with a single [container] registers it under the name of the service.
+	containers, err := resolveContainers("web", &TaskDefinitionContainerDefinitionInputs{
+		PortMappings: []TaskDefinitionPortMappingInputs{
+			{Name: "http", ContainerPort: pulumi.IntPtr(80)},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containerName, containerPort, err := serviceDiscoveryPort(&ServiceDiscoveryInputs{PortName: "http"}, containers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if port := knownPort(containerPort); containerName != "web" || port == nil || *port != 80 {
+		t.Errorf("expected port 80 of container web, got %v of container %q", port, containerName)
+	}
+}
+
+func TestServiceDiscoveryPortRejectsInputs(t *testing.T) {
+	containers := map[string]TaskDefinitionContainerDefinitionInputs{
+		"app": {
+			PortMappings: []TaskDefinitionPortMappingInputs{
+				{Name: "http", ContainerPort: pulumi.IntPtr(8080)},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		inputs     ServiceDiscoveryInputs
+		containers map[string]TaskDefinitionContainerDefinitionInputs
+		problem    string
+	}{
+		"container without a port": {
+			inputs:     ServiceDiscoveryInputs{ContainerName: "app"},
+			containers: containers,
+			problem:    "must be specified together",
+		},
+		"port name and port": {
+			inputs:     ServiceDiscoveryInputs{ContainerPort: 80, PortName: "http"},
+			containers: containers,
+			problem:    "Only one of [portName] or [containerPort]",
+		},
+		"port name without a task definition": {
+			inputs:  ServiceDiscoveryInputs{PortName: "http"},
+			problem: "requires the task definition to be created by the service",
+		},
+		"unknown port name": {
+			inputs:     ServiceDiscoveryInputs{PortName: "grpc"},
+			containers: containers,
+			problem:    "no container has a port mapping named grpc",
+		},
+		"port name of another container": {
+			inputs:     ServiceDiscoveryInputs{ContainerName: "sidecar", PortName: "http"},
+			containers: containers,
+			problem:    "no container has a port mapping named http",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := serviceDiscoveryPort(&c.inputs, c.containers)
+			if err == nil || !strings.Contains(err.Error(), c.problem) {
+				t.Errorf("expected an error containing %q, got %v", c.problem, err)
+			}
+		})
+	}
+}
+
+func intRef(i int) *int {
+	return &i
+}
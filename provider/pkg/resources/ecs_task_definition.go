@@ -0,0 +1,185 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// taskDefinitionContainersInputs are the inputs shared by the EC2 and Fargate task definitions that
+// shape their containers.
+type taskDefinitionContainersInputs struct {
+	Container     *TaskDefinitionContainerDefinitionInputs
+	Containers    map[string]TaskDefinitionContainerDefinitionInputs
+	EFS           *TaskDefinitionEFSInputs
+	Lint          taskDefinitionLint
+	LogGroup      *DefaultLogGroupInputs
+	LogRouter     *TaskDefinitionLogRouterInputs
+	Observability *TaskDefinitionObservabilityInputs
+	Tags          map[string]string
+}
+
+type taskDefinitionContainersResult struct {
+	Containers    map[string]TaskDefinitionContainerDefinitionInputs
+	EFS           *taskDefinitionEFSResult
+	Images        *taskDefinitionImagesResult
+	LogGroup      *LogGroupResult
+	LogRouter     *taskDefinitionLogRouterResult
+	Observability *taskDefinitionObservabilityResult
+	Secrets       *taskDefinitionSecretsResult
+}
+
+// LogGroupID returns the log group the containers log to by default, or nil if the log group of the
+// task definition is skipped.
+func (r *taskDefinitionContainersResult) LogGroupID() *pulumi.AnyOutput {
+	if r.LogGroup == nil {
+		return nil
+	}
+
+	return &r.LogGroup.LogGroupID
+}
+
+// buildTaskDefinitionContainers resolves and checks the containers of a task definition, then builds
+// their images, secrets, EFS volumes and log group, and adds the observability and log router sidecars,
+// which are checked along with the containers.
+func buildTaskDefinitionContainers(ctx *pulumi.Context, name string, inputs *taskDefinitionContainersInputs, parent pulumi.Resource, opts ...pulumi.ResourceOption) (*taskDefinitionContainersResult, error) {
+	containers, err := resolveContainers(name, inputs.Container, inputs.Containers)
+	if err != nil {
+		return nil, err
+	}
+
+	// The containers are checked before any resource is created for them, and once more with the
+	// mount points and sidecars added below.
+	err = lintContainerDefinitions(containers, inputs.Lint)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &taskDefinitionContainersResult{Containers: containers}
+
+	result.Images, err = taskDefinitionImages(ctx, name, containers, parent, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Secrets, err = taskDefinitionSecrets(ctx, name, containers, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.EFS, err = taskDefinitionEFS(ctx, name, inputs.EFS, containers, inputs.Tags, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.LogGroup, err = defaultLogGroup(ctx, name, inputs.LogGroup, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Observability, err = taskDefinitionObservability(ctx, inputs.Observability, containers, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.LogRouter, err = taskDefinitionLogRouter(ctx, inputs.LogRouter, containers, result.LogGroup, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lintContainerDefinitions(containers, inputs.Lint)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// taskDefinitionRolesInputs are the inputs shared by the EC2 and Fargate task definitions that shape
+// their task and execution roles.
+type taskDefinitionRolesInputs struct {
+	ExecuteCommand *serviceExecuteCommandConfiguration
+	ExecutionRole  *DefaultRoleWithPolicyInputs
+	Grants         *TaskDefinitionGrantsInputs
+	TaskRole       *DefaultRoleWithPolicyInputs
+}
+
+type taskDefinitionRolesResult struct {
+	ExecutionRole *defaultRoleWithPoliciesResult
+	TaskRole      *defaultRoleWithPoliciesResult
+}
+
+// attachTaskRolePolicies creates the task and execution roles of a task definition, unless existing
+// roles are given, and grants them what the containers need.
+func attachTaskRolePolicies(ctx *pulumi.Context, name string, inputs *taskDefinitionRolesInputs, containers *taskDefinitionContainersResult, opts ...pulumi.ResourceOption) (*taskDefinitionRolesResult, error) {
+	defaultPolicyDoc, err := defaultRoleAssumeRolePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taskRoleName := fmt.Sprintf("%s-task", name)
+
+	if inputs.TaskRole.Args == nil && inputs.TaskRole.RoleARN == "" {
+		inputs.TaskRole.Args = &RoleWithPolicyInputs{}
+	}
+
+	taskRole, err := defaultRoleWithPolicies(ctx, taskRoleName, *inputs.TaskRole, defaultPolicyDoc.Json, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = taskRoleGrantsPolicy(ctx, taskRoleName, inputs.Grants, taskRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = taskRoleEFSPolicy(ctx, taskRoleName, containers.EFS, taskRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = taskRoleLogRouterPolicy(ctx, taskRoleName, containers.LogRouter, taskRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = taskRoleObservabilityPolicy(ctx, taskRoleName, containers.Observability, taskRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = taskRoleExecuteCommandPolicy(ctx, taskRoleName, inputs.ExecuteCommand, taskRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	executionRoleName := fmt.Sprintf("%s-execution", name)
+
+	if inputs.ExecutionRole.Args == nil && inputs.ExecutionRole.RoleARN == "" {
+		inputs.ExecutionRole.Args = &RoleWithPolicyInputs{}
+	}
+
+	if inputs.ExecutionRole.Args != nil && len(inputs.ExecutionRole.Args.PolicyARNs) == 0 {
+		inputs.ExecutionRole.Args.PolicyARNs = defaultExecutionRolePolicyARNs()
+	}
+
+	executionRole, err := defaultRoleWithPolicies(ctx, executionRoleName, *inputs.ExecutionRole, defaultPolicyDoc.Json, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = executionRoleSecretsPolicy(ctx, executionRoleName, containers.Secrets, executionRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = executionRoleImagesPolicy(ctx, executionRoleName, containers.Images, executionRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskDefinitionRolesResult{
+		ExecutionRole: executionRole,
+		TaskRole:      taskRole,
+	}, nil
+}
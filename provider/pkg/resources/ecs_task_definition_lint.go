@@ -0,0 +1,292 @@
+package resources
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	validDependencyConditions = []string{"COMPLETE", "HEALTHY", "START", "SUCCESS"}
+	validHealthCheckCommands  = []string{"CMD", "CMD-SHELL", "NONE"}
+	validUlimitNames          = []string{
+		"core", "cpu", "data", "fsize", "locks", "memlock", "msgqueue", "nice",
+		"nofile", "nproc", "rss", "rtprio", "rttime", "sigpending", "stack",
+	}
+)
+
+// taskDefinitionLint holds the settings of the task definition its containers are checked against.
+type taskDefinitionLint struct {
+	Fargate bool
+	// Memory is the memory of the task, in MiB or with a `GB` unit, or empty if the task has none.
+	Memory      string
+	NetworkMode string
+}
+
+// lintContainerDefinitions checks the containers of a task definition against the rules ECS enforces
+// when the task definition is registered, so that misconfigured tasks fail before any resource is
+// created. Only the plain values of the containers are checked, ports given as outputs are skipped.
+// Every problem found is reported, prefixed with the name of its container.
+func lintContainerDefinitions(containers map[string]TaskDefinitionContainerDefinitionInputs, task taskDefinitionLint) error {
+	var problems []string
+	problemf := func(containerName, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("Container %s: %s", containerName, fmt.Sprintf(format, args...)))
+	}
+
+	var names []string
+	for containerName := range containers {
+		names = append(names, containerName)
+	}
+	sort.Strings(names)
+
+	var definitions []renderedContainerDefinition
+	for _, containerName := range names {
+		definition := renderedContainerDefinition{
+			TaskDefinitionContainerDefinitionInputs: containers[containerName],
+		}
+		definition.Name = containerName
+
+		for _, mapping := range definition.TaskDefinitionContainerDefinitionInputs.PortMappings {
+			definition.PortMappings = append(definition.PortMappings, renderedPortMapping{
+				ContainerPort: knownPort(mapping.ContainerPort),
+				HostPort:      knownPort(mapping.HostPort),
+				Protocol:      string(mapping.Protocol),
+			})
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	byName := map[string]renderedContainerDefinition{}
+	for _, definition := range definitions {
+		byName[definition.Name] = definition
+	}
+
+	essential := false
+	for _, definition := range definitions {
+		if definition.Essential == nil || *definition.Essential {
+			essential = true
+		}
+	}
+
+	if !essential {
+		problems = append(problems, "At least one container must be [essential]")
+	}
+
+	for _, definition := range definitions {
+		for _, dependency := range definition.DependsOn {
+			target, ok := byName[dependency.ContainerName]
+			switch {
+			case dependency.ContainerName == definition.Name:
+				problemf(definition.Name, "[dependsOn] cannot reference the container itself")
+			case !ok:
+				problemf(definition.Name, "[dependsOn] references container %s, which does not exist", dependency.ContainerName)
+			case dependency.Condition == "HEALTHY" && target.HealthCheck == nil:
+				problemf(definition.Name, "[dependsOn] waits for container %s to be HEALTHY, which has no [healthCheck]", dependency.ContainerName)
+			}
+
+			if !containsString(validDependencyConditions, dependency.Condition) {
+				problemf(definition.Name, "[dependsOn] condition %q is not one of %v", dependency.Condition, validDependencyConditions)
+			}
+		}
+	}
+
+	if _, err := sortContainerDefinitions(definitions); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	problems = append(problems, lintPortMappings(definitions, task)...)
+	problems = append(problems, lintMemory(definitions, task)...)
+
+	for _, definition := range definitions {
+		if task.Fargate {
+			if definition.Privileged {
+				problemf(definition.Name, "[privileged] is not supported on Fargate")
+			}
+
+			if definition.LinuxParameters != nil && len(definition.LinuxParameters.Devices) > 0 {
+				problemf(definition.Name, "[linuxParameters] [devices] are not supported on Fargate")
+			}
+
+			if len(definition.Links) > 0 {
+				problemf(definition.Name, "[links] are not supported on Fargate")
+			}
+		}
+
+		if healthCheck := definition.HealthCheck; healthCheck != nil {
+			if len(healthCheck.Command) == 0 || !containsString(validHealthCheckCommands, healthCheck.Command[0]) {
+				problemf(definition.Name, "[healthCheck] [command] must start with one of %v", validHealthCheckCommands)
+			}
+
+			for _, bound := range []struct {
+				name        string
+				value       int
+				min, max    int
+				zeroIsUnset bool
+			}{
+				{"interval", healthCheck.Interval, 5, 300, true},
+				{"retries", healthCheck.Retries, 1, 10, true},
+				{"startPeriod", healthCheck.StartPeriod, 0, 300, false},
+				{"timeout", healthCheck.Timeout, 2, 60, true},
+			} {
+				if bound.zeroIsUnset && bound.value == 0 {
+					continue
+				}
+
+				if bound.value < bound.min || bound.value > bound.max {
+					problemf(definition.Name, "[healthCheck] [%s] must be between %d and %d, got %d", bound.name, bound.min, bound.max, bound.value)
+				}
+			}
+		}
+
+		for _, ulimit := range definition.Ulimits {
+			if !containsString(validUlimitNames, ulimit.Name) {
+				problemf(definition.Name, "ulimit %q is not one of %v", ulimit.Name, validUlimitNames)
+			}
+
+			if ulimit.SoftLimit > ulimit.HardLimit {
+				problemf(definition.Name, "ulimit %s has a [softLimit] greater than its [hardLimit]", ulimit.Name)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("Invalid task definition:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// lintPortMappings checks that no container maps the same port twice and, for network modes that
+// expose the ports of the containers on the host, that no two containers use the same host port.
+func lintPortMappings(definitions []renderedContainerDefinition, task taskDefinitionLint) []string {
+	var problems []string
+
+	networkMode := task.NetworkMode
+	if networkMode == "" {
+		networkMode = "bridge"
+	}
+
+	hostPorts := map[string]string{}
+	for _, definition := range definitions {
+		containerPorts := map[string]bool{}
+		for _, mapping := range definition.PortMappings {
+			protocol := strings.ToLower(mapping.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+
+			// The container port defaults to the host port.
+			if mapping.ContainerPort == nil {
+				mapping.ContainerPort = mapping.HostPort
+			}
+
+			if mapping.ContainerPort != nil {
+				key := fmt.Sprintf("%d/%s", *mapping.ContainerPort, protocol)
+				if containerPorts[key] {
+					problems = append(problems, fmt.Sprintf("Container %s: container port %s is mapped more than once", definition.Name, key))
+				}
+				containerPorts[key] = true
+			}
+
+			if task.Fargate && mapping.HostPort != nil && mapping.ContainerPort != nil && *mapping.HostPort != *mapping.ContainerPort {
+				problems = append(problems, fmt.Sprintf("Container %s: [hostPort] %d must equal [containerPort] %d on Fargate", definition.Name, *mapping.HostPort, *mapping.ContainerPort))
+			}
+
+			// In bridge mode a missing or zero host port is assigned dynamically, in awsvpc and host
+			// mode the host port is the container port.
+			hostPort := mapping.HostPort
+			if networkMode != "bridge" && hostPort == nil {
+				hostPort = mapping.ContainerPort
+			}
+
+			if networkMode == "none" || hostPort == nil || *hostPort == 0 {
+				continue
+			}
+
+			key := fmt.Sprintf("%d/%s", *hostPort, protocol)
+			if other, ok := hostPorts[key]; ok && other != definition.Name {
+				problems = append(problems, fmt.Sprintf("Container %s: host port %s is also used by container %s", definition.Name, key, other))
+			}
+			hostPorts[key] = definition.Name
+		}
+	}
+
+	return problems
+}
+
+// lintMemory checks the memory of the containers against each other and against the memory of the
+// task.
+func lintMemory(definitions []renderedContainerDefinition, task taskDefinitionLint) []string {
+	var problems []string
+
+	taskMemoryMiB := 0.0
+	if task.Memory != "" {
+		memGB, err := parseFargateSize(task.Memory, "GB")
+		if err != nil {
+			return []string{err.Error()}
+		}
+
+		taskMemoryMiB = memGB * 1024
+	}
+
+	requiredMiB := 0
+	for _, definition := range definitions {
+		if definition.Memory > 0 && definition.MemoryReservation > definition.Memory {
+			problems = append(problems, fmt.Sprintf("Container %s: [memoryReservation] %d MiB exceeds [memory] %d MiB", definition.Name, definition.MemoryReservation, definition.Memory))
+		}
+
+		if taskMemoryMiB > 0 && float64(definition.Memory) > taskMemoryMiB {
+			problems = append(problems, fmt.Sprintf("Container %s: [memory] %d MiB exceeds the %v MiB of the task", definition.Name, definition.Memory, taskMemoryMiB))
+		}
+
+		if definition.MemoryReservation > 0 {
+			requiredMiB += definition.MemoryReservation
+		} else {
+			requiredMiB += definition.Memory
+		}
+	}
+
+	if taskMemoryMiB > 0 && float64(requiredMiB) > taskMemoryMiB {
+		problems = append(problems, fmt.Sprintf("The containers reserve %d MiB, which exceeds the %v MiB of the task", requiredMiB, taskMemoryMiB))
+	}
+
+	return problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// knownPort returns the port of a port mapping if it is given as a plain value, rather than as an
+// output that is only known once it is resolved.
+func knownPort(port pulumi.IntPtrInput) *int {
+	if port == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(port)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Int {
+		return nil
+	}
+
+	result := int(value.Int())
+	return &result
+}
@@ -0,0 +1,32 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestBuildTaskDefinitionContainersLintsSidecars(t *testing.T) {
+	// The containers fit in the task on their own, the observability collector added to them does not.
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		_, err := buildTaskDefinitionContainers(ctx, "task", &taskDefinitionContainersInputs{
+			Container: &TaskDefinitionContainerDefinitionInputs{
+				Image:             "app:1",
+				MemoryReservation: 450,
+			},
+			Lint: taskDefinitionLint{
+				Fargate:     true,
+				Memory:      "512",
+				NetworkMode: "awsvpc",
+			},
+			LogGroup:      &DefaultLogGroupInputs{},
+			Observability: &TaskDefinitionObservabilityInputs{Traces: true},
+		}, nil)
+
+		return err
+	}, pulumi.WithMocks("project", "stack", containerDefinitionMocks{}))
+	if err == nil || !strings.Contains(err.Error(), "exceeds the 512 MiB of the task") {
+		t.Errorf("expected the collector to push the containers over the memory of the task, got %v", err)
+	}
+}
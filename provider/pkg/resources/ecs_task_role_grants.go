@@ -0,0 +1,166 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	grantAccessRead      = "read"
+	grantAccessWrite     = "write"
+	grantAccessReadWrite = "readWrite"
+)
+
+type TaskDefinitionGrantInputs struct {
+	Access string             `pulumi:"access"`
+	Arn    pulumi.StringInput `pulumi:"arn"`
+}
+
+type TaskDefinitionGrantsInputs struct {
+	Buckets []TaskDefinitionGrantInputs `pulumi:"buckets"`
+	Queues  []TaskDefinitionGrantInputs `pulumi:"queues"`
+	Tables  []TaskDefinitionGrantInputs `pulumi:"tables"`
+	Topics  []TaskDefinitionGrantInputs `pulumi:"topics"`
+}
+
+// taskRoleGrantActions are the actions granted on each kind of resource for reading and writing.
+var taskRoleGrantActions = map[string]map[string][]string{
+	"buckets": {
+		grantAccessRead:  {"s3:GetObject", "s3:GetObjectVersion", "s3:ListBucket"},
+		grantAccessWrite: {"s3:PutObject", "s3:DeleteObject", "s3:AbortMultipartUpload"},
+	},
+	"queues": {
+		grantAccessRead:  {"sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility", "sqs:GetQueueAttributes", "sqs:GetQueueUrl"},
+		grantAccessWrite: {"sqs:SendMessage", "sqs:GetQueueAttributes", "sqs:GetQueueUrl"},
+	},
+	"tables": {
+		grantAccessRead:  {"dynamodb:BatchGetItem", "dynamodb:ConditionCheckItem", "dynamodb:DescribeTable", "dynamodb:GetItem", "dynamodb:Query", "dynamodb:Scan"},
+		grantAccessWrite: {"dynamodb:BatchWriteItem", "dynamodb:DeleteItem", "dynamodb:PutItem", "dynamodb:UpdateItem"},
+	},
+	"topics": {
+		grantAccessWrite: {"sns:Publish"},
+	},
+}
+
+type taskRoleGrant struct {
+	Access string
+	Arn    pulumi.StringInput
+	Kind   string
+}
+
+// grants lists the grants of every kind with their access defaulted: topics can only be published to,
+// other resources are read by default.
+func (g *TaskDefinitionGrantsInputs) grants() ([]taskRoleGrant, error) {
+	var result []taskRoleGrant
+	kinds := []struct {
+		Kind   string
+		Grants []TaskDefinitionGrantInputs
+	}{
+		{"buckets", g.Buckets},
+		{"queues", g.Queues},
+		{"tables", g.Tables},
+		{"topics", g.Topics},
+	}
+
+	for _, kind := range kinds {
+		for _, grant := range kind.Grants {
+			if grant.Arn == nil {
+				return nil, fmt.Errorf("[grants] [%s] require an [arn]", kind.Kind)
+			}
+
+			access := grant.Access
+			if access == "" {
+				access = grantAccessRead
+				if kind.Kind == "topics" {
+					access = grantAccessWrite
+				}
+			}
+
+			if access != grantAccessRead && access != grantAccessWrite && access != grantAccessReadWrite {
+				return nil, fmt.Errorf("Unknown [grants] [%s] access %s, must be one of %s, %s or %s", kind.Kind, access, grantAccessRead, grantAccessWrite, grantAccessReadWrite)
+			}
+
+			if kind.Kind == "topics" && access != grantAccessWrite {
+				return nil, fmt.Errorf("[grants] [topics] can only be granted %s access", grantAccessWrite)
+			}
+
+			result = append(result, taskRoleGrant{
+				Access: access,
+				Arn:    grant.Arn,
+				Kind:   kind.Kind,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// taskRoleGrantStatement scopes the actions of a grant to its resource, including the objects of a
+// bucket and the indexes of a table.
+func taskRoleGrantStatement(grant taskRoleGrant, arn string) iam.GetPolicyDocumentStatement {
+	var actions []string
+	if grant.Access == grantAccessRead || grant.Access == grantAccessReadWrite {
+		actions = append(actions, taskRoleGrantActions[grant.Kind][grantAccessRead]...)
+	}
+
+	if grant.Access == grantAccessWrite || grant.Access == grantAccessReadWrite {
+		for _, action := range taskRoleGrantActions[grant.Kind][grantAccessWrite] {
+			actions = appendUnique(actions, action)
+		}
+	}
+
+	resources := []string{arn}
+	switch grant.Kind {
+	case "buckets":
+		resources = append(resources, fmt.Sprintf("%s/*", arn))
+	case "tables":
+		resources = append(resources, fmt.Sprintf("%s/index/*", arn))
+	}
+
+	return iam.GetPolicyDocumentStatement{
+		Actions:   actions,
+		Resources: resources,
+	}
+}
+
+// taskRoleGrantsPolicy adds an inline policy to the task role allowing exactly the declared access to
+// the granted resources.
+func taskRoleGrantsPolicy(ctx *pulumi.Context, name string, inputs *TaskDefinitionGrantsInputs, taskRole *defaultRoleWithPoliciesResult, opts ...pulumi.ResourceOption) (*iam.RolePolicy, error) {
+	if inputs == nil {
+		return nil, nil
+	}
+
+	grants, err := inputs.grants()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(grants) == 0 {
+		return nil, nil
+	}
+
+	if taskRole == nil || taskRole.Role == nil {
+		return nil, fmt.Errorf("[grants] can only be used with a task role created by the task definition")
+	}
+
+	var arns []interface{}
+	for _, grant := range grants {
+		arns = append(arns, grant.Arn)
+	}
+
+	policy := pulumi.All(arns...).ApplyT(func(values []interface{}) (string, error) {
+		var statements []iam.GetPolicyDocumentStatement
+		for i, value := range values {
+			statements = append(statements, taskRoleGrantStatement(grants[i], value.(string)))
+		}
+
+		return allowPolicyDocument(ctx, statements)
+	}).(pulumi.StringOutput)
+
+	return iam.NewRolePolicy(ctx, fmt.Sprintf("%s-grants", name), &iam.RolePolicyArgs{
+		Role:   taskRole.Role.Name,
+		Policy: policy,
+	}, append(opts, pulumi.Parent(taskRole.Role))...)
+}
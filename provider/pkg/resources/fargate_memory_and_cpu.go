@@ -1,21 +1,96 @@
 package resources
 
 import (
+	_ "embed"
+	"encoding/json"
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 )
 
-func newRange(start, end int) []float64 {
-	result := make([]float64, end-start+1)
-	for i := start; i <= end; i++ {
-		result = append(result, float64(i))
+const (
+	fargateMaxVCPU            = 16
+	fargateMaxMemoryGB        = 120
+	fargateDefaultPriceRegion = "us-east-1"
+)
+
+// fargatePricingData holds the hourly Fargate prices of every region, by CPU architecture and by
+// on-demand or Spot capacity.
+//
+//go:embed data/fargate_pricing.json
+var fargatePricingData []byte
+
+type fargatePrice struct {
+	GB   float64 `json:"gb"`
+	VCPU float64 `json:"vcpu"`
+}
+
+type fargateArchitecturePrices struct {
+	OnDemand fargatePrice `json:"onDemand"`
+	Spot     fargatePrice `json:"spot"`
+}
+
+type fargateRegionPrices map[string]fargateArchitecturePrices
+
+// fargatePrices returns the prices of the region, falling back to the prices of us-east-1 for regions
+// without price data.
+func fargatePrices(region string) (fargateRegionPrices, error) {
+	var pricing map[string]fargateRegionPrices
+	err := json.Unmarshal(fargatePricingData, &pricing)
+	if err != nil {
+		return nil, err
 	}
-	return result
+
+	prices, ok := pricing[region]
+	if !ok {
+		prices = pricing[fargateDefaultPriceRegion]
+	}
+
+	return prices, nil
+}
+
+// architecturePrices returns the prices of the CPU architecture, where anything but ARM64 is priced
+// as x86.
+func (p fargateRegionPrices) architecturePrices(cpuArchitecture string) fargateArchitecturePrices {
+	if strings.EqualFold(cpuArchitecture, "ARM64") {
+		return p["arm64"]
+	}
+
+	return p["x86_64"]
 }
 
-func fargateCost(vcpu, memGB float64) float64 {
-	return (0.04048 * vcpu) + (0.004445 * memGB)
+func (p fargatePrice) cost(vcpu, memGB float64) float64 {
+	return (p.VCPU * vcpu) + (p.GB * memGB)
+}
+
+// estimatedHourlyCost prices a task, with the share of tasks run on FARGATE_SPOT given by the weights of
+// the capacity provider strategies.
+func (p fargateArchitecturePrices) estimatedHourlyCost(vcpu, memGB float64, strategies []ClusterCapacityProviderStrategyInputs) float64 {
+	totalWeight := 0
+	spotWeight := 0
+	for _, strategy := range strategies {
+		totalWeight += strategy.Weight
+		if strategy.CapacityProvider == "FARGATE_SPOT" {
+			spotWeight += strategy.Weight
+		}
+	}
+
+	if totalWeight == 0 {
+		return p.OnDemand.cost(vcpu, memGB)
+	}
+
+	spotShare := float64(spotWeight) / float64(totalWeight)
+	return (1-spotShare)*p.OnDemand.cost(vcpu, memGB) + spotShare*p.Spot.cost(vcpu, memGB)
+}
+
+func newRange(start, end, step int) []float64 {
+	result := make([]float64, 0, (end-start)/step+1)
+	for i := start; i <= end; i += step {
+		result = append(result, float64(i))
+	}
+	return result
 }
 
 type cpuMemoryConfig struct {
@@ -24,13 +99,13 @@ type cpuMemoryConfig struct {
 	Cost  float64
 }
 
-func newCpuMemoryConfigs(vcpu float64, memGBs []float64) []cpuMemoryConfig {
-	result := make([]cpuMemoryConfig, len(memGBs))
+func newCpuMemoryConfigs(price fargatePrice, vcpu float64, memGBs []float64) []cpuMemoryConfig {
+	result := make([]cpuMemoryConfig, 0, len(memGBs))
 	for _, memGB := range memGBs {
 		result = append(result, cpuMemoryConfig{
 			Vcpu:  vcpu,
 			MemGB: memGB,
-			Cost:  fargateCost(vcpu, memGB),
+			Cost:  price.cost(vcpu, memGB),
 		})
 	}
 	return result
@@ -41,21 +116,28 @@ func sortByCost(configs []cpuMemoryConfig) func(x, y int) bool {
 		config1 := configs[x]
 		config2 := configs[y]
 
-		if config1.Cost <= config2.Cost {
-			return true
+		if config1.Cost != config2.Cost {
+			return config1.Cost < config2.Cost
 		}
 
-		return false
+		if config1.Vcpu != config2.Vcpu {
+			return config1.Vcpu < config2.Vcpu
+		}
+
+		return config1.MemGB < config2.MemGB
 	}
 }
 
-func fargateConfigsByPriceAscending() []cpuMemoryConfig {
+// fargateConfigsByPriceAscending lists the supported task sizes, cheapest first.
+func fargateConfigsByPriceAscending(price fargatePrice) []cpuMemoryConfig {
 	allConfigs := []cpuMemoryConfig{}
-	allConfigs = append(allConfigs, newCpuMemoryConfigs(0.25, []float64{0.5, 1, 2})...)
-	allConfigs = append(allConfigs, newCpuMemoryConfigs(0.5, newRange(1, 4))...)
-	allConfigs = append(allConfigs, newCpuMemoryConfigs(1, newRange(2, 8))...)
-	allConfigs = append(allConfigs, newCpuMemoryConfigs(2, newRange(4, 16))...)
-	allConfigs = append(allConfigs, newCpuMemoryConfigs(4, newRange(8, 30))...)
+	allConfigs = append(allConfigs, newCpuMemoryConfigs(price, 0.25, []float64{0.5, 1, 2})...)
+	allConfigs = append(allConfigs, newCpuMemoryConfigs(price, 0.5, newRange(1, 4, 1))...)
+	allConfigs = append(allConfigs, newCpuMemoryConfigs(price, 1, newRange(2, 8, 1))...)
+	allConfigs = append(allConfigs, newCpuMemoryConfigs(price, 2, newRange(4, 16, 1))...)
+	allConfigs = append(allConfigs, newCpuMemoryConfigs(price, 4, newRange(8, 30, 1))...)
+	allConfigs = append(allConfigs, newCpuMemoryConfigs(price, 8, newRange(16, 60, 4))...)
+	allConfigs = append(allConfigs, newCpuMemoryConfigs(price, 16, newRange(32, 120, 8))...)
 
 	sort.SliceStable(allConfigs, sortByCost(allConfigs))
 	return allConfigs
@@ -77,21 +159,21 @@ type fargateContainerMemoryAndCpuResult struct {
 	Cpu    string
 }
 
-func calculateFargateMemoryAndCPU(containers []fargateContainerMemoryAndCpu) (*fargateContainerMemoryAndCpuResult, error) {
+func calculateFargateMemoryAndCPU(containers []fargateContainerMemoryAndCpu, price fargatePrice) (*fargateContainerMemoryAndCpuResult, error) {
 	// First, determine how much VCPU/GB that the user is asking for in their containers.
 	requested := getRequestedVCPUandMemory(containers)
 
-	// Max CPU that can be requested is only 4.  Don't exceed that.  No need to worry about a
+	// Max CPU that can be requested is only 16.  Don't exceed that.  No need to worry about a
 	// min as we're finding the first config that provides *at least* this amount.
-	requestedVCPU := math.Min(requested.RequestedVCPU, 4)
+	requestedVCPU := math.Min(requested.RequestedVCPU, fargateMaxVCPU)
 
-	// Max memory that can be requested is only 30.  Don't exceed that.  No need to worry about
+	// Max memory that can be requested is only 120.  Don't exceed that.  No need to worry about
 	// a min as we're finding the first config that provides *at least* this amount.
-	requestedGB := math.Min(requested.RequestedGB, 30)
+	requestedGB := math.Min(requested.RequestedGB, fargateMaxMemoryGB)
 
 	// Get all configs that can at least satisfy this pair of cpu/memory needs.
 	var config *cpuMemoryConfig
-	allConfigs := fargateConfigsByPriceAscending()
+	allConfigs := fargateConfigsByPriceAscending(price)
 	for _, c := range allConfigs {
 		if (c.Vcpu >= requestedVCPU) && (c.MemGB >= requestedGB) {
 			config = &c
@@ -141,3 +223,24 @@ func getRequestedVCPUandMemory(containers []fargateContainerMemoryAndCpu) reques
 		RequestedGB:   requestedGB,
 	}
 }
+
+// parseFargateSize converts the cpu or memory of a task definition, given in CPU units or MiB, or with
+// a `vCPU` or `GB` unit, to vCPUs or GB.
+func parseFargateSize(value, unit string) (float64, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasSuffix(strings.ToLower(trimmed), strings.ToLower(unit)) {
+		size, err := strconv.ParseFloat(strings.TrimSpace(trimmed[:len(trimmed)-len(unit)]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid Fargate size %s", value)
+		}
+
+		return size, nil
+	}
+
+	size, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid Fargate size %s", value)
+	}
+
+	return size / 1024, nil
+}
@@ -0,0 +1,160 @@
+package resources
+
+import (
+	"math"
+	"testing"
+)
+
+var testFargatePrice = fargatePrice{GB: 0.004445, VCPU: 0.04048}
+
+func TestCalculateFargateMemoryAndCPU(t *testing.T) {
+	cases := map[string]struct {
+		containers []fargateContainerMemoryAndCpu
+		cpu        string
+		memory     string
+	}{
+		"smallest": {
+			cpu:    "256",
+			memory: "512",
+		},
+		"reservation over memory": {
+			containers: []fargateContainerMemoryAndCpu{{Cpu: 1024, Memory: 8192, MemoryReservation: 3072}},
+			cpu:        "1024",
+			memory:     "3072",
+		},
+		"summed containers": {
+			containers: []fargateContainerMemoryAndCpu{{Cpu: 512, Memory: 1024}, {Cpu: 512, Memory: 1024}},
+			cpu:        "1024",
+			memory:     "2048",
+		},
+		"8 vCPU": {
+			containers: []fargateContainerMemoryAndCpu{{Cpu: 6144, Memory: 8192}},
+			cpu:        "8192",
+			memory:     "16384",
+		},
+		"8 vCPU in steps of 4 GB": {
+			containers: []fargateContainerMemoryAndCpu{{Cpu: 8192, Memory: 17 * 1024}},
+			cpu:        "8192",
+			memory:     "20480",
+		},
+		"16 vCPU": {
+			containers: []fargateContainerMemoryAndCpu{{Cpu: 8192, Memory: 62 * 1024}},
+			cpu:        "16384",
+			memory:     "65536",
+		},
+		"capped at 16 vCPU and 120 GB": {
+			containers: []fargateContainerMemoryAndCpu{{Cpu: 20480, Memory: 200 * 1024}},
+			cpu:        "16384",
+			memory:     "122880",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := calculateFargateMemoryAndCPU(c.containers, testFargatePrice)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Cpu != c.cpu || result.Memory != c.memory {
+				t.Errorf("expected %s CPU and %s memory, got %s CPU and %s memory", c.cpu, c.memory, result.Cpu, result.Memory)
+			}
+		})
+	}
+}
+
+func TestFargateConfigsByPriceAscending(t *testing.T) {
+	configs := fargateConfigsByPriceAscending(testFargatePrice)
+
+	counts := map[float64]int{}
+	for i, config := range configs {
+		if config.Vcpu == 0 || config.MemGB == 0 {
+			t.Errorf("unexpected empty config %+v", config)
+		}
+
+		if i > 0 && configs[i-1].Cost > config.Cost {
+			t.Errorf("config %+v is cheaper than the config before it %+v", config, configs[i-1])
+		}
+
+		counts[config.Vcpu]++
+	}
+
+	for vcpu, count := range map[float64]int{0.25: 3, 0.5: 4, 1: 7, 2: 13, 4: 23, 8: 12, 16: 12} {
+		if counts[vcpu] != count {
+			t.Errorf("expected %d configs with %v vCPU, got %d", count, vcpu, counts[vcpu])
+		}
+	}
+}
+
+func TestParseFargateSize(t *testing.T) {
+	cases := []struct {
+		value string
+		unit  string
+		size  float64
+	}{
+		{"1 vCPU", "vCPU", 1},
+		{"0.25 vcpu", "vCPU", 0.25},
+		{"1024", "vCPU", 1},
+		{"2GB", "GB", 2},
+		{"0.5 GB", "GB", 0.5},
+		{"2048", "GB", 2},
+	}
+
+	for _, c := range cases {
+		size, err := parseFargateSize(c.value, c.unit)
+		if err != nil {
+			t.Errorf("parsing %q: %v", c.value, err)
+			continue
+		}
+
+		if size != c.size {
+			t.Errorf("expected %q to be %v %s, got %v", c.value, c.size, c.unit, size)
+		}
+	}
+
+	for _, value := range []string{"", "two GB", "2 TB"} {
+		if _, err := parseFargateSize(value, "GB"); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestEstimatedHourlyCost(t *testing.T) {
+	prices := fargateArchitecturePrices{
+		OnDemand: fargatePrice{GB: 0.1, VCPU: 1},
+		Spot:     fargatePrice{GB: 0.03, VCPU: 0.3},
+	}
+
+	cases := map[string]struct {
+		strategies []ClusterCapacityProviderStrategyInputs
+		cost       float64
+	}{
+		"on demand": {
+			cost: 1.2,
+		},
+		"spot": {
+			strategies: []ClusterCapacityProviderStrategyInputs{{CapacityProvider: "FARGATE_SPOT", Weight: 1}},
+			cost:       0.36,
+		},
+		"weighted": {
+			strategies: []ClusterCapacityProviderStrategyInputs{
+				{CapacityProvider: "FARGATE", Weight: 1},
+				{CapacityProvider: "FARGATE_SPOT", Weight: 3},
+			},
+			cost: 0.25*1.2 + 0.75*0.36,
+		},
+		"base only": {
+			strategies: []ClusterCapacityProviderStrategyInputs{{Base: 1, CapacityProvider: "FARGATE_SPOT"}},
+			cost:       1.2,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			cost := prices.estimatedHourlyCost(1, 2, c.strategies)
+			if math.Abs(cost-c.cost) > 1e-9 {
+				t.Errorf("expected a cost of %v, got %v", c.cost, cost)
+			}
+		})
+	}
+}
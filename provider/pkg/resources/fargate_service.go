@@ -3,44 +3,63 @@ package resources
 import (
 	"fmt"
 
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/appautoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/servicediscovery"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 const FargateServiceIdentifier = "awsx-go:ecs:FargateService"
 
+type FargateServiceSpotInputs struct {
+	Base   int `pulumi:"base"`
+	Weight int `pulumi:"weight"`
+}
+
 type FargateServiceArgs struct {
-	Cluster                         pulumi.StringOutput                         `pulumi:"cluster"`
-	ContinueBeforeSteadyState       bool                                        `pulumi:"continueBeforeSteadyState"`
-	DeploymentCircuitBreaker        ecs.ServiceDeploymentCircuitBreakerPtrInput `pulumi:"deploymentCircuitBreaker"`
-	DeploymentController            ecs.ServiceDeploymentControllerPtrInput     `pulumi:"deploymentController"`
-	DeploymentMaximumPercent        int                                         `pulumi:"deploymentMaximumPercent"`
-	DeploymentMinimumHealthyPercent int                                         `pulumi:"deploymentMinimumHealthyPercent"`
-	DesiredCount                    int                                         `pulumi:"desiredCount"`
-	EnableEcsManagedTags            bool                                        `pulumi:"enableEcsManagedTags"`
-	EnableExecuteCommand            bool                                        `pulumi:"enableExecuteCommand"`
-	ForceNewDeployment              bool                                        `pulumi:"forceNewDeployment"`
-	HealthCheckGracePeriodSeconds   int                                         `pulumi:"healthCheckGracePeriodSeconds"`
-	IAMRole                         string                                      `pulumi:"iamRole"`
-	LoadBalancers                   *ecs.ServiceLoadBalancerArrayOutput         `pulumi:"loadBalancers"`
-	Name                            string                                      `pulumi:"name"`
-	NetworkConfiguration            ecs.ServiceNetworkConfigurationPtrInput     `pulumi:"networkConfiguration"`
-	PlacementConstraints            ecs.ServicePlacementConstraintArrayInput    `pulumi:"placementConstraints"`
-	PlatformVersions                string                                      `pulumi:"platformVersions"`
-	PropagateTags                   string                                      `pulumi:"propagateTags"`
-	SchedulingStrategy              string                                      `pulumi:"schedulingStrategy"`
-	ServiceRegistries               ecs.ServiceServiceRegistriesPtrInput        `pulumi:"serviceRegistries"`
-	Tags                            map[string]string                           `pulumi:"tags"`
-	TaskDefinition                  string                                      `pulumi:"taskDefinition"`
-	TaskDefinitionArgs              *FargateTaskDefinitionArgs                  `pulumi:"taskDefinitionArgs"`
+	AutoScaling                               *ServiceAutoScalingInputs                   `pulumi:"autoScaling"`
+	CapacityProviderStrategies                []ClusterCapacityProviderStrategyInputs     `pulumi:"capacityProviderStrategies"`
+	Cluster                                   pulumi.StringInput                          `pulumi:"cluster"`
+	ContinueBeforeSteadyState                 bool                                        `pulumi:"continueBeforeSteadyState"`
+	DeploymentCircuitBreaker                  ecs.ServiceDeploymentCircuitBreakerPtrInput `pulumi:"deploymentCircuitBreaker"`
+	DeploymentController                      ecs.ServiceDeploymentControllerPtrInput     `pulumi:"deploymentController"`
+	DeploymentMaximumPercent                  int                                         `pulumi:"deploymentMaximumPercent"`
+	DeploymentMinimumHealthyPercent           int                                         `pulumi:"deploymentMinimumHealthyPercent"`
+	DesiredCount                              int                                         `pulumi:"desiredCount"`
+	EnableEcsManagedTags                      bool                                        `pulumi:"enableEcsManagedTags"`
+	EnableExecuteCommand                      bool                                        `pulumi:"enableExecuteCommand"`
+	ForceNewDeployment                        bool                                        `pulumi:"forceNewDeployment"`
+	HealthCheckGracePeriodSeconds             int                                         `pulumi:"healthCheckGracePeriodSeconds"`
+	IAMRole                                   string                                      `pulumi:"iamRole"`
+	LaunchType                                string                                      `pulumi:"launchType"`
+	LoadBalancers                             *ecs.ServiceLoadBalancerArrayOutput         `pulumi:"loadBalancers"`
+	Name                                      string                                      `pulumi:"name"`
+	NetworkConfiguration                      ecs.ServiceNetworkConfigurationPtrInput     `pulumi:"networkConfiguration"`
+	PlacementConstraints                      ecs.ServicePlacementConstraintArrayInput    `pulumi:"placementConstraints"`
+	PlatformVersions                          string                                      `pulumi:"platformVersions"`
+	PropagateTags                             string                                      `pulumi:"propagateTags"`
+	SchedulingStrategy                        string                                      `pulumi:"schedulingStrategy"`
+	ServiceDiscovery                          *ServiceDiscoveryInputs                     `pulumi:"serviceDiscovery"`
+	ServiceRegistries                         ecs.ServiceServiceRegistriesPtrInput        `pulumi:"serviceRegistries"`
+	Spot                                      *FargateServiceSpotInputs                   `pulumi:"spot"`
+	Tags                                      map[string]string                           `pulumi:"tags"`
+	TaskDefinition                            string                                      `pulumi:"taskDefinition"`
+	TaskDefinitionArgs                        *FargateTaskDefinitionArgs                  `pulumi:"taskDefinitionArgs"`
+	UseClusterDefaultCapacityProviderStrategy bool                                        `pulumi:"useClusterDefaultCapacityProviderStrategy"`
 }
 
 type FargateService struct {
 	pulumi.ResourceState
 
-	Service        *ecs.Service           `pulumi:"service"`
-	TaskDefinition *FargateTaskDefinition `pulumi:"taskDefinition"`
+	AutoScalingAlarms   []*cloudwatch.MetricAlarm             `pulumi:"autoScalingAlarms"`
+	AutoScalingPolicies []*appautoscaling.Policy              `pulumi:"autoScalingPolicies"`
+	AutoScalingTarget   *appautoscaling.Target                `pulumi:"autoScalingTarget"`
+	DiscoveryNamespace  *servicediscovery.PrivateDnsNamespace `pulumi:"discoveryNamespace"`
+	DiscoveryService    *servicediscovery.Service             `pulumi:"discoveryService"`
+	Service             *ecs.Service                          `pulumi:"service"`
+	TaskDefinition      *FargateTaskDefinition                `pulumi:"taskDefinition"`
 }
 
 func NewFargateService(ctx *pulumi.Context, name string, args *FargateServiceArgs, opts ...pulumi.ResourceOption) (*FargateService, error) {
@@ -64,6 +83,37 @@ func NewFargateService(ctx *pulumi.Context, name string, args *FargateServiceArg
 		return nil, fmt.Errorf("Either `taskDefinition` or `taskDefinitionArgs` must be provided.")
 	}
 
+	launchType, capacityProviderStrategies, err := fargateServiceCapacityProviderStrategies(args)
+	if err != nil {
+		return nil, err
+	}
+
+	executeCommand, err := serviceExecuteCommand(ctx, name, args.EnableExecuteCommand, args.Cluster, args.TaskDefinition, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.TaskDefinitionArgs != nil {
+		// Tasks run on FARGATE_SPOT are priced as such.
+		args.TaskDefinitionArgs.capacityProviderStrategies = capacityProviderStrategies
+		args.TaskDefinitionArgs.executeCommand = executeCommand
+	}
+
+	usesDefaultNetworkConfiguration := args.NetworkConfiguration == nil
+	if usesDefaultNetworkConfiguration {
+		args.NetworkConfiguration, err = getDefaultNetworkConfiguration(ctx, name, component)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if args.TaskDefinitionArgs != nil && args.TaskDefinitionArgs.EFS != nil {
+		err = defaultServiceEFSNetwork(ctx, args.TaskDefinitionArgs.EFS, args.NetworkConfiguration, usesDefaultNetworkConfiguration, component)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var taskDefinition *FargateTaskDefinition
 	var taskDefinitionIdentifier pulumi.StringOutput
 	if args.TaskDefinition != "" {
@@ -84,13 +134,6 @@ func NewFargateService(ctx *pulumi.Context, name string, args *FargateServiceArg
 		args.DesiredCount = 1
 	}
 
-	if args.NetworkConfiguration == nil {
-		args.NetworkConfiguration, err = getDefaultNetworkConfiguration(ctx, name, component)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	if args.LoadBalancers == nil && taskDefinition != nil {
 		args.LoadBalancers = &taskDefinition.LoadBalancers
 	}
@@ -105,8 +148,48 @@ func NewFargateService(ctx *pulumi.Context, name string, args *FargateServiceArg
 		schedulingStrategy = pulumi.StringPtr(args.SchedulingStrategy)
 	}
 
+	var cluster pulumi.StringPtrInput
+	if args.Cluster != nil {
+		cluster = args.Cluster.ToStringOutput().ToStringPtrOutput()
+	}
+
+	var containers map[string]TaskDefinitionContainerDefinitionInputs
+	if args.TaskDefinitionArgs != nil {
+		// The task definition is named after the service, as is its container if there is only one.
+		containers, err = resolveContainers(name, args.TaskDefinitionArgs.Container, args.TaskDefinitionArgs.Containers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	discovery, err := serviceDiscovery(ctx, name, args.ServiceDiscovery, args.ServiceRegistries, "awsvpc", containers, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	component.DiscoveryNamespace = discovery.Namespace
+	component.DiscoveryService = discovery.Service
+
+	serviceOpts := opts
+	if args.UseClusterDefaultCapacityProviderStrategy {
+		// ECS fills in the cluster's default strategy, which must not be seen as a change.
+		serviceOpts = append(serviceOpts, pulumi.IgnoreChanges([]string{"capacityProviderStrategies"}))
+	}
+
+	if args.AutoScaling != nil {
+		err = args.AutoScaling.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		// Application Auto Scaling owns the desired count once the service exists.
+		args.DesiredCount = args.AutoScaling.InitialDesiredCount(args.DesiredCount)
+		serviceOpts = append(serviceOpts, pulumi.IgnoreChanges([]string{"desiredCount"}))
+	}
+
 	service, err := ecs.NewService(ctx, name, &ecs.ServiceArgs{
-		Cluster:                         args.Cluster,
+		CapacityProviderStrategies:      serviceCapacityProviderStrategies(capacityProviderStrategies),
+		Cluster:                         cluster,
 		DeploymentCircuitBreaker:        args.DeploymentCircuitBreaker,
 		DeploymentController:            args.DeploymentController,
 		DeploymentMaximumPercent:        pulumi.IntPtr(args.DeploymentMaximumPercent),
@@ -117,7 +200,7 @@ func NewFargateService(ctx *pulumi.Context, name string, args *FargateServiceArg
 		ForceNewDeployment:              pulumi.BoolPtr(args.ForceNewDeployment),
 		HealthCheckGracePeriodSeconds:   pulumi.IntPtr(args.HealthCheckGracePeriodSeconds),
 		IamRole:                         pulumi.StringPtr(args.IAMRole),
-		LaunchType:                      pulumi.String("FARGATE"),
+		LaunchType:                      launchType,
 		LoadBalancers:                   args.LoadBalancers,
 		Name:                            pulumi.StringPtr(args.Name),
 		NetworkConfiguration:            args.NetworkConfiguration,
@@ -125,22 +208,178 @@ func NewFargateService(ctx *pulumi.Context, name string, args *FargateServiceArg
 		PlatformVersion:                 pulumi.StringPtr(args.PlatformVersions),
 		PropagateTags:                   propagateTags,
 		SchedulingStrategy:              schedulingStrategy,
-		ServiceRegistries:               args.ServiceRegistries,
+		ServiceRegistries:               discovery.ServiceRegistries,
 		Tags:                            pulumi.ToStringMap(args.Tags),
 		TaskDefinition:                  taskDefinitionIdentifier,
 		WaitForSteadyState:              pulumi.BoolPtr(args.ContinueBeforeSteadyState),
-	}, opts...)
+	}, serviceOpts...)
 	if err != nil {
 		return nil, err
 	}
 
 	component.Service = service
 
+	if args.AutoScaling != nil {
+		autoScaling, err := serviceAutoScaling(ctx, name, args.AutoScaling, service, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.AutoScalingAlarms = autoScaling.Alarms
+		component.AutoScalingPolicies = autoScaling.Policies
+		component.AutoScalingTarget = autoScaling.Target
+	}
+
 	return component, nil
 }
 
+// fargateServiceCapacityProviderStrategies resolves how the tasks of the service are placed: with a
+// launch type, which defaults to FARGATE, with capacity provider strategies, which can mix FARGATE and
+// FARGATE_SPOT with the [spot] shorthand, or with the default strategy of the cluster. ECS rejects a
+// launch type alongside capacity provider strategies, so only one of them is set.
+func fargateServiceCapacityProviderStrategies(args *FargateServiceArgs) (pulumi.StringPtrInput, []ClusterCapacityProviderStrategyInputs, error) {
+	specified := 0
+	for _, isSpecified := range []bool{
+		args.LaunchType != "",
+		len(args.CapacityProviderStrategies) > 0,
+		args.Spot != nil,
+		args.UseClusterDefaultCapacityProviderStrategy,
+	} {
+		if isSpecified {
+			specified++
+		}
+	}
+
+	if specified > 1 {
+		return nil, nil, fmt.Errorf("Only one of `launchType`, `capacityProviderStrategies`, `spot` or `useClusterDefaultCapacityProviderStrategy` can be provided.")
+	}
+
+	if args.UseClusterDefaultCapacityProviderStrategy {
+		return nil, nil, nil
+	}
+
+	strategies := args.CapacityProviderStrategies
+	if args.Spot != nil {
+		weight := args.Spot.Weight
+		if weight == 0 {
+			weight = 1
+		}
+
+		strategies = []ClusterCapacityProviderStrategyInputs{
+			{
+				CapacityProvider: "FARGATE",
+				Weight:           1,
+			},
+			{
+				Base:             args.Spot.Base,
+				CapacityProvider: "FARGATE_SPOT",
+				Weight:           weight,
+			},
+		}
+	}
+
+	if len(strategies) == 0 {
+		launchType := args.LaunchType
+		if launchType == "" {
+			launchType = "FARGATE"
+		}
+
+		if launchType != "FARGATE" {
+			return nil, nil, fmt.Errorf("Unknown launch type %s, Fargate services must use FARGATE", launchType)
+		}
+
+		return pulumi.StringPtr(launchType), nil, nil
+	}
+
+	err := validateCapacityProviderStrategies([]string{"FARGATE", "FARGATE_SPOT"}, strategies)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nil, strategies, nil
+}
+
+func serviceCapacityProviderStrategies(strategies []ClusterCapacityProviderStrategyInputs) ecs.ServiceCapacityProviderStrategyArray {
+	var capacityProviderStrategies ecs.ServiceCapacityProviderStrategyArray
+	for _, strategy := range strategies {
+		capacityProviderStrategies = append(capacityProviderStrategies, &ecs.ServiceCapacityProviderStrategyArgs{
+			Base:             pulumi.IntPtr(strategy.Base),
+			CapacityProvider: pulumi.String(strategy.CapacityProvider),
+			Weight:           pulumi.IntPtr(strategy.Weight),
+		})
+	}
+
+	return capacityProviderStrategies
+}
+
+// defaultServiceEFSNetwork reaches the file system of the task definition from the tasks of the
+// service: NFS is allowed from the security groups of the service, and mount targets are created in
+// one subnet of every availability zone of the default VPC when the service runs in it.
+func defaultServiceEFSNetwork(ctx *pulumi.Context, inputs *TaskDefinitionEFSInputs, networkConfiguration ecs.ServiceNetworkConfigurationPtrInput, usesDefaultNetworkConfiguration bool, parent pulumi.Resource) error {
+	if inputs.FileSystemID != nil {
+		return nil
+	}
+
+	if inputs.SecurityGroupIDs == nil {
+		inputs.SecurityGroupIDs = networkConfiguration.ToServiceNetworkConfigurationPtrOutput().SecurityGroups()
+	}
+
+	if len(inputs.SubnetIDs) > 0 {
+		return nil
+	}
+
+	if !usesDefaultNetworkConfiguration {
+		subnetIDs, ok := knownNetworkConfigurationSubnets(networkConfiguration)
+		if !ok {
+			return fmt.Errorf("[efs] [subnetIds] must be specified when the subnets of the [networkConfiguration] are only known once deployed")
+		}
+
+		inputs.SubnetIDs = subnetIDs
+		return nil
+	}
+
+	defaultVpc, err := getDefaultVPC(ctx, nil, pulumi.Parent(parent))
+	if err != nil {
+		return err
+	}
+
+	for _, availabilityZone := range defaultVpc.AvailabilityZones {
+		if len(availabilityZone.PublicSubnetIDs) > 0 {
+			inputs.SubnetIDs = append(inputs.SubnetIDs, availabilityZone.PublicSubnetIDs[0])
+		}
+	}
+
+	return nil
+}
+
+// knownNetworkConfigurationSubnets returns the subnets of a network configuration if they are given as
+// plain values, rather than as outputs that are only known once they are resolved.
+func knownNetworkConfigurationSubnets(networkConfiguration ecs.ServiceNetworkConfigurationPtrInput) ([]string, bool) {
+	args, ok := networkConfiguration.(*ecs.ServiceNetworkConfigurationArgs)
+	if !ok || args == nil {
+		return nil, false
+	}
+
+	subnets, ok := args.Subnets.(pulumi.StringArray)
+	if !ok || len(subnets) == 0 {
+		return nil, false
+	}
+
+	var subnetIDs []string
+	for _, subnet := range subnets {
+		subnetID, ok := subnet.(pulumi.String)
+		if !ok {
+			return nil, false
+		}
+
+		subnetIDs = append(subnetIDs, string(subnetID))
+	}
+
+	return subnetIDs, true
+}
+
 func getDefaultNetworkConfiguration(ctx *pulumi.Context, name string, parent pulumi.Resource) (*ecs.ServiceNetworkConfigurationArgs, error) {
-	defaultVpc, err := getDefaultVPC(ctx, pulumi.Parent(parent))
+	defaultVpc, err := getDefaultVPC(ctx, nil, pulumi.Parent(parent))
 	if err != nil {
 		return nil, err
 	}
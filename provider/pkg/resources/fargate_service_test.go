@@ -0,0 +1,111 @@
+package resources
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestFargateServiceCapacityProviderStrategies(t *testing.T) {
+	cases := map[string]struct {
+		args       FargateServiceArgs
+		launchType pulumi.StringPtrInput
+		strategies []ClusterCapacityProviderStrategyInputs
+	}{
+		"default": {
+			launchType: pulumi.StringPtr("FARGATE"),
+		},
+		"launch type": {
+			args:       FargateServiceArgs{LaunchType: "FARGATE"},
+			launchType: pulumi.StringPtr("FARGATE"),
+		},
+		"capacity provider strategies": {
+			args: FargateServiceArgs{
+				CapacityProviderStrategies: []ClusterCapacityProviderStrategyInputs{{CapacityProvider: "FARGATE_SPOT", Weight: 1}},
+			},
+			strategies: []ClusterCapacityProviderStrategyInputs{{CapacityProvider: "FARGATE_SPOT", Weight: 1}},
+		},
+		"spot": {
+			args: FargateServiceArgs{Spot: &FargateServiceSpotInputs{Base: 2, Weight: 3}},
+			strategies: []ClusterCapacityProviderStrategyInputs{
+				{CapacityProvider: "FARGATE", Weight: 1},
+				{Base: 2, CapacityProvider: "FARGATE_SPOT", Weight: 3},
+			},
+		},
+		"spot with the default weight": {
+			args: FargateServiceArgs{Spot: &FargateServiceSpotInputs{}},
+			strategies: []ClusterCapacityProviderStrategyInputs{
+				{CapacityProvider: "FARGATE", Weight: 1},
+				{CapacityProvider: "FARGATE_SPOT", Weight: 1},
+			},
+		},
+		"cluster default": {
+			args: FargateServiceArgs{UseClusterDefaultCapacityProviderStrategy: true},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			launchType, strategies, err := fargateServiceCapacityProviderStrategies(&c.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(launchType, c.launchType) {
+				t.Errorf("expected launch type %v, got %v", c.launchType, launchType)
+			}
+
+			if !reflect.DeepEqual(strategies, c.strategies) {
+				t.Errorf("expected strategies %v, got %v", c.strategies, strategies)
+			}
+		})
+	}
+}
+
+func TestFargateServiceCapacityProviderStrategiesRejectsArgs(t *testing.T) {
+	strategies := []ClusterCapacityProviderStrategyInputs{{CapacityProvider: "FARGATE", Weight: 1}}
+	exclusive := "Only one of `launchType`, `capacityProviderStrategies`, `spot` or `useClusterDefaultCapacityProviderStrategy`"
+
+	cases := map[string]struct {
+		args    FargateServiceArgs
+		problem string
+	}{
+		"launch type and strategies": {
+			args:    FargateServiceArgs{LaunchType: "FARGATE", CapacityProviderStrategies: strategies},
+			problem: exclusive,
+		},
+		"launch type and spot": {
+			args:    FargateServiceArgs{LaunchType: "FARGATE", Spot: &FargateServiceSpotInputs{}},
+			problem: exclusive,
+		},
+		"strategies and spot": {
+			args:    FargateServiceArgs{CapacityProviderStrategies: strategies, Spot: &FargateServiceSpotInputs{}},
+			problem: exclusive,
+		},
+		"spot and cluster default": {
+			args:    FargateServiceArgs{Spot: &FargateServiceSpotInputs{}, UseClusterDefaultCapacityProviderStrategy: true},
+			problem: exclusive,
+		},
+		"EC2 launch type": {
+			args:    FargateServiceArgs{LaunchType: "EC2"},
+			problem: "Fargate services must use FARGATE",
+		},
+		"EC2 capacity provider": {
+			args: FargateServiceArgs{
+				CapacityProviderStrategies: []ClusterCapacityProviderStrategyInputs{{CapacityProvider: "my-asg", Weight: 1}},
+			},
+			problem: "Capacity provider my-asg is used in a strategy",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := fargateServiceCapacityProviderStrategies(&c.args)
+			if err == nil || !strings.Contains(err.Error(), c.problem) {
+				t.Errorf("expected an error containing %q, got %v", c.problem, err)
+			}
+		})
+	}
+}
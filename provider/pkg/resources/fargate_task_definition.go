@@ -1,12 +1,15 @@
 package resources
 
 import (
-	"encoding/json"
 	"fmt"
 
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecr"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/efs"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/secretsmanager"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/zchase/pulumi-awsx-go/pkg/utils"
 )
@@ -14,15 +17,20 @@ import (
 const FargateTaskDefinitionIdentifier = "awsx-go:ecs:FargateTaskDefinition"
 
 type FargateTaskDefinitionArgs struct {
+	Container             *TaskDefinitionContainerDefinitionInputs           `pulumi:"container"`
 	Containers            map[string]TaskDefinitionContainerDefinitionInputs `pulumi:"containers"`
 	CPU                   string                                             `pulumi:"cpu"`
 	EphemeralStorage      ecs.TaskDefinitionEphemeralStoragePtrInput         `pulumi:"ephemeralStorage"`
+	EFS                   *TaskDefinitionEFSInputs                           `pulumi:"efs"`
 	ExecutionRole         DefaultRoleWithPolicyInputs                        `pulumi:"executionRole"`
 	Family                string                                             `pulumi:"family"`
+	Grants                *TaskDefinitionGrantsInputs                        `pulumi:"grants"`
 	InferenceAccelerators ecs.TaskDefinitionInferenceAcceleratorArrayInput   `pulumi:"inferenceAccelerators"`
 	IPCMode               string                                             `pulumi:"ipcMode"`
 	LogGroup              DefaultLogGroupInputs                              `pulumi:"logGroup"`
+	LogRouter             *TaskDefinitionLogRouterInputs                     `pulumi:"logRouter"`
 	Memory                string                                             `pulumi:"memory"`
+	Observability         *TaskDefinitionObservabilityInputs                 `pulumi:"observability"`
 	PIDMode               string                                             `pulumi:"pidMode"`
 	PlacementConstraints  ecs.TaskDefinitionPlacementConstraintArrayInput    `pulumi:"placementConstraints"`
 	ProxyConfiguration    ecs.TaskDefinitionProxyConfigurationPtrInput       `pulumi:"proxyConfiguration"`
@@ -31,16 +39,28 @@ type FargateTaskDefinitionArgs struct {
 	Tags                  map[string]string                                  `pulumi:"tags"`
 	TaskRole              DefaultRoleWithPolicyInputs                        `pulumi:"taskRole"`
 	Volumes               ecs.TaskDefinitionVolumeArrayInput                 `pulumi:"volumes"`
+
+	// capacityProviderStrategies are the strategies of the service running the tasks, used to price them.
+	capacityProviderStrategies []ClusterCapacityProviderStrategyInputs
+	// executeCommand is set by the service running the tasks when ECS Exec is enabled.
+	executeCommand *serviceExecuteCommandConfiguration
 }
 
 type FargateTaskDefinition struct {
 	pulumi.ResourceState
 
-	ExecutionRole  *iam.Role                          `pulumi:"executionRole"`
-	LoadBalancers  ecs.ServiceLoadBalancerArrayOutput `pulumi:"loadBalancers"`
-	LogGroup       *cloudwatch.LogGroup               `pulumi:"logGroup"`
-	TaskDefinition *ecs.TaskDefinition                `pulumi:"taskDefinition"`
-	TaskRole       *iam.Role                          `pulumi:"taskRole"`
+	AccessPoints        []*efs.AccessPoint                 `pulumi:"accessPoints"`
+	CPU                 pulumi.StringOutput                `pulumi:"cpu"`
+	EstimatedHourlyCost pulumi.Float64Output               `pulumi:"estimatedHourlyCost"`
+	ExecutionRole       *iam.Role                          `pulumi:"executionRole"`
+	FileSystem          *efs.FileSystem                    `pulumi:"fileSystem"`
+	LoadBalancers       ecs.ServiceLoadBalancerArrayOutput `pulumi:"loadBalancers"`
+	LogGroup            *cloudwatch.LogGroup               `pulumi:"logGroup"`
+	Memory              pulumi.StringOutput                `pulumi:"memory"`
+	Repository          *ecr.Repository                    `pulumi:"repository"`
+	TaskDefinition      *ecs.TaskDefinition                `pulumi:"taskDefinition"`
+	Secrets             []*secretsmanager.Secret           `pulumi:"secrets"`
+	TaskRole            *iam.Role                          `pulumi:"taskRole"`
 }
 
 func NewFargateTaskDefinition(ctx *pulumi.Context, name string, args *FargateTaskDefinitionArgs, opts ...pulumi.ResourceOption) (*FargateTaskDefinition, error) {
@@ -56,72 +76,101 @@ func NewFargateTaskDefinition(ctx *pulumi.Context, name string, args *FargateTas
 
 	opts = append(opts, pulumi.Parent(component))
 
-	dLogGroup, err := defaultLogGroup(ctx, name, &args.LogGroup, opts...)
+	taskContainers, err := buildTaskDefinitionContainers(ctx, name, &taskDefinitionContainersInputs{
+		Container:  args.Container,
+		Containers: args.Containers,
+		EFS:        args.EFS,
+		Lint: taskDefinitionLint{
+			Fargate:     true,
+			Memory:      args.Memory,
+			NetworkMode: "awsvpc",
+		},
+		LogGroup:      &args.LogGroup,
+		LogRouter:     args.LogRouter,
+		Observability: args.Observability,
+		Tags:          args.Tags,
+	}, component, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	component.LogGroup = dLogGroup.LogGroup
-
-	taskRoleName := fmt.Sprintf("%s-task", name)
+	args.Containers = taskContainers.Containers
+	args.Volumes = mergeTaskDefinitionVolumes(args.Volumes, taskContainers.EFS.Volumes)
 
-	if args.TaskRole.Args == nil {
-		args.TaskRole.Args = &RoleWithPolicyInputs{}
+	component.AccessPoints = taskContainers.EFS.AccessPoints
+	component.FileSystem = taskContainers.EFS.FileSystem
+	component.Repository = taskContainers.Images.Repository
+	component.Secrets = taskContainers.Secrets.Secrets
+	if taskContainers.LogGroup != nil {
+		component.LogGroup = taskContainers.LogGroup.LogGroup
 	}
 
-	if len(args.TaskRole.Args.PolicyARNs) == 0 {
-		args.TaskRole.Args.PolicyARNs = defaultExecutionRolePolicyARNs()
-	}
-
-	defaultPolicyDoc, err := defaultRoleAssumeRolePolicy(ctx)
+	roles, err := attachTaskRolePolicies(ctx, name, &taskDefinitionRolesInputs{
+		ExecuteCommand: args.executeCommand,
+		ExecutionRole:  &args.ExecutionRole,
+		Grants:         args.Grants,
+		TaskRole:       &args.TaskRole,
+	}, taskContainers, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	taskRole, err := defaultRoleWithPolicies(ctx, taskRoleName, args.TaskRole, defaultPolicyDoc.Json, opts...)
+	component.ExecutionRole = roles.ExecutionRole.Role
+	component.TaskRole = roles.TaskRole.Role
+
+	containerDefinitions := computeContainerDefinitions(component, args.Containers, taskContainers.LogGroupID())
+
+	component.LoadBalancers = computeLoadBalancers(containerDefinitions)
+
+	region, err := aws.GetRegion(ctx, nil, invokeOptions(opts)...)
 	if err != nil {
 		return nil, err
 	}
-	component.TaskRole = taskRole.Role
-
-	executionRoleName := fmt.Sprintf("%s-execution", name)
 
-	if args.ExecutionRole.Args == nil {
-		args.ExecutionRole.Args = &RoleWithPolicyInputs{}
+	prices, err := fargatePrices(region.Name)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(args.ExecutionRole.Args.PolicyARNs) == 0 {
-		args.ExecutionRole.Args.PolicyARNs = defaultExecutionRolePolicyARNs()
+	// Tasks are sized by their x86 on-demand price, which is proportional to the other prices.
+	taskDefinitionArgs, err := buildFargateTaskDefinitionArgs(ctx, name, args, containerDefinitions, roles.TaskRole.RoleARN, roles.ExecutionRole.RoleARN, prices.architecturePrices("X86_64").OnDemand)
+	if err != nil {
+		return nil, err
 	}
 
-	executionRole, err := defaultRoleWithPolicies(ctx, executionRoleName, args.ExecutionRole, defaultPolicyDoc.Json, opts...)
+	vcpu, err := parseFargateSize(args.CPU, "vCPU")
 	if err != nil {
 		return nil, err
 	}
-	component.ExecutionRole = executionRole.Role
-
-	containerDefinitions := computeContainerDefinitions(component, args.Containers, &dLogGroup.LogGroupID)
 
-	component.LoadBalancers = utils.ApplyAny(computeLoadBalancers(ctx, args.Containers), func(lbs ecs.ServiceLoadBalancerArrayOutput) ecs.ServiceLoadBalancerArrayOutput {
-		return lbs
-	})
-
-	taskDefinitionArgs, err := buildFargateTaskDefinitionArgs(ctx, name, args, containerDefinitions, taskRole.RoleARN, executionRole.RoleARN)
+	memGB, err := parseFargateSize(args.Memory, "GB")
 	if err != nil {
 		return nil, err
 	}
 
-	taskDefinition, err := ecs.NewTaskDefinition(ctx, name, taskDefinitionArgs, opts...)
+	cpuArchitecture := pulumi.String("X86_64").ToStringOutput()
+	if args.RuntimePlatform != nil {
+		cpuArchitecture = args.RuntimePlatform.ToTaskDefinitionRuntimePlatformPtrOutput().CpuArchitecture().Elem()
+	}
+
+	strategies := args.capacityProviderStrategies
+	component.EstimatedHourlyCost = cpuArchitecture.ApplyT(func(cpuArchitecture string) float64 {
+		return prices.architecturePrices(cpuArchitecture).estimatedHourlyCost(vcpu, memGB, strategies)
+	}).(pulumi.Float64Output)
+
+	taskDefinition, err := ecs.NewTaskDefinition(ctx, name, taskDefinitionArgs, append(opts, pulumi.DependsOn(taskContainers.EFS.DependsOn()))...)
 	if err != nil {
 		return nil, err
 	}
 
+	component.CPU = taskDefinition.Cpu.Elem()
+	component.Memory = taskDefinition.Memory.Elem()
 	component.TaskDefinition = taskDefinition
 
 	return component, nil
 }
 
-func buildFargateTaskDefinitionArgs(ctx *pulumi.Context, name string, args *FargateTaskDefinitionArgs, containerDefinitions []TaskDefinitionContainerDefinitionInputs, taskRoleARN, executionRoleARN pulumi.StringOutput) (*ecs.TaskDefinitionArgs, error) {
+func buildFargateTaskDefinitionArgs(ctx *pulumi.Context, name string, args *FargateTaskDefinitionArgs, containerDefinitions []TaskDefinitionContainerDefinitionInputs, taskRoleARN, executionRoleARN pulumi.StringOutput, price fargatePrice) (*ecs.TaskDefinitionArgs, error) {
 	var memoryAndCPUContainerDefs []fargateContainerMemoryAndCpu
 	for _, def := range containerDefinitions {
 		memoryAndCPUContainerDefs = append(memoryAndCPUContainerDefs, fargateContainerMemoryAndCpu{
@@ -130,7 +179,7 @@ func buildFargateTaskDefinitionArgs(ctx *pulumi.Context, name string, args *Farg
 			MemoryReservation: float64(def.MemoryReservation),
 		})
 	}
-	requiredMemoryAndCPU, err := calculateFargateMemoryAndCPU(memoryAndCPUContainerDefs)
+	requiredMemoryAndCPU, err := calculateFargateMemoryAndCPU(memoryAndCPUContainerDefs, price)
 	if err != nil {
 		return nil, err
 	}
@@ -143,23 +192,22 @@ func buildFargateTaskDefinitionArgs(ctx *pulumi.Context, name string, args *Farg
 		args.Memory = fmt.Sprintf("%v", requiredMemoryAndCPU.Memory)
 	}
 
-	containerDefJSON, err := json.Marshal(containerDefinitions)
-	if err != nil {
-		return nil, err
-	}
+	containerDefJSON := resolveContainerDefinitions(containerDefinitions)
 
-	containerDefHash := utils.SHA1Hash(fmt.Sprintf("%s%s", ctx.Stack(), string(containerDefJSON)))
-	defaultFamily := fmt.Sprintf("%s-%s", name, containerDefHash)
+	family := pulumi.String(args.Family).ToStringOutput()
 	if args.Family == "" {
-		args.Family = defaultFamily
+		family = containerDefJSON.ApplyT(func(containerDefJSON string) string {
+			containerDefHash := utils.SHA1Hash(fmt.Sprintf("%s%s", ctx.Stack(), containerDefJSON))
+			return fmt.Sprintf("%s-%s", name, containerDefHash)
+		}).(pulumi.StringOutput)
 	}
 
 	result := &ecs.TaskDefinitionArgs{
-		ContainerDefinitions:    pulumi.String(string(containerDefJSON)),
+		ContainerDefinitions:    containerDefJSON,
 		Cpu:                     pulumi.StringPtr(args.CPU),
 		EphemeralStorage:        args.EphemeralStorage,
 		ExecutionRoleArn:        executionRoleARN,
-		Family:                  pulumi.String(args.Family),
+		Family:                  family,
 		InferenceAccelerators:   args.InferenceAccelerators,
 		Memory:                  pulumi.StringPtr(args.Memory),
 		NetworkMode:             pulumi.String("awsvpc"),
@@ -2,23 +2,45 @@ package resources
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-type DefaultVPCOutput struct {
-	VPCID            pulumi.StringOutput
-	PrivateSubnetIDs pulumi.StringArrayOutput
-	PublicSubnetIDs  pulumi.StringArrayOutput
+type DefaultVPCSubnet struct {
+	AvailabilityZone   string `pulumi:"availabilityZone"`
+	AvailabilityZoneID string `pulumi:"availabilityZoneId"`
+	CIDRBlock          string `pulumi:"cidrBlock"`
+	ID                 string `pulumi:"id"`
+	Type               string `pulumi:"type"`
+}
+
+func (s DefaultVPCSubnet) IsPublic() bool {
+	return strings.ToLower(s.Type) == "public"
 }
 
-type defaultSubnetOutput struct {
-	PrivateSubnetIDs pulumi.StringArrayOutput
-	PublicSubnetIDs  pulumi.StringArrayOutput
+type DefaultVPCAvailabilityZone struct {
+	Name             string             `pulumi:"name"`
+	PrivateSubnetIDs []string           `pulumi:"privateSubnetIds"`
+	PublicSubnetIDs  []string           `pulumi:"publicSubnetIds"`
+	Subnets          []DefaultVPCSubnet `pulumi:"subnets"`
 }
 
-func getDefaultVPC(ctx *pulumi.Context, opts ...pulumi.InvokeOption) (*DefaultVPCOutput, error) {
+type DefaultVPCOutput struct {
+	AvailabilityZones []DefaultVPCAvailabilityZone
+	Subnets           []DefaultVPCSubnet
+	VPCID             pulumi.StringOutput
+	PrivateSubnetIDs  pulumi.StringArrayOutput
+	PublicSubnetIDs   pulumi.StringArrayOutput
+}
+
+// getDefaultVPC looks up the default VPC and its subnets. A subnet is classified as public when the
+// route table it is associated with (or the VPC's main route table, if it has no explicit association)
+// has a default route to an internet gateway. Subnets are sorted by availability zone and CIDR block,
+// and can be limited to the given availability zones.
+func getDefaultVPC(ctx *pulumi.Context, availabilityZoneNames []string, opts ...pulumi.InvokeOption) (*DefaultVPCOutput, error) {
 	vpc, err := ec2.LookupVpc(ctx, &ec2.LookupVpcArgs{
 		Default: pulumi.BoolRef(true),
 	}, opts...)
@@ -30,59 +52,153 @@ func getDefaultVPC(ctx *pulumi.Context, opts ...pulumi.InvokeOption) (*DefaultVP
 		return nil, fmt.Errorf("unable to find default VPC for this region and account")
 	}
 
-	subnetOutput := ec2.GetSubnetsOutput(ctx, ec2.GetSubnetsOutputArgs{
-		Filters: ec2.GetSubnetsFilterArray{
-			&ec2.GetSubnetsFilterArgs{
-				Name:   pulumi.String("vpc-id"),
-				Values: pulumi.ToStringArray([]string{vpc.Id}),
-			},
+	subnetFilters := []ec2.GetSubnetsFilter{
+		{
+			Name:   "vpc-id",
+			Values: []string{vpc.Id},
 		},
+	}
+
+	if len(availabilityZoneNames) > 0 {
+		subnetFilters = append(subnetFilters, ec2.GetSubnetsFilter{
+			Name:   "availability-zone",
+			Values: availabilityZoneNames,
+		})
+	}
+
+	subnetIDs, err := ec2.GetSubnets(ctx, &ec2.GetSubnetsArgs{
+		Filters: subnetFilters,
 	}, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-	subnets := subnetOutput.Ids().ApplyT(func(subnetIDs []string) []ec2.LookupSubnetResultOutput {
-		var result []ec2.LookupSubnetResultOutput
-		for _, id := range subnetIDs {
-			lookupResult := ec2.LookupSubnetOutput(ctx, ec2.LookupSubnetOutputArgs{
-				Id: pulumi.StringPtr(id),
-			}, opts...)
+	publicRouteTables, mainRouteTableIsPublic, err := getPublicRouteTables(ctx, vpc.Id, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-			result = append(result, lookupResult)
+	var subnets []DefaultVPCSubnet
+	for _, id := range subnetIDs.Ids {
+		subnet, err := ec2.LookupSubnet(ctx, &ec2.LookupSubnetArgs{
+			Id: pulumi.StringRef(id),
+		}, opts...)
+		if err != nil {
+			return nil, err
 		}
 
-		return result
-	}).(pulumi.AnyOutput)
+		isPublic, hasExplicitRouteTable := publicRouteTables[subnet.Id]
+		if !hasExplicitRouteTable {
+			isPublic = mainRouteTableIsPublic
+		}
 
-	subnetValues := subnets.ApplyT(func(subs interface{}) defaultSubnetOutput {
-		var publicSubnetIDs []pulumi.StringOutput
-		var privateSubnetIDs []pulumi.StringOutput
+		subnetType := "Private"
+		if isPublic {
+			subnetType = "Public"
+		}
 
-		switch subs := subs.(type) {
-		case []ec2.LookupSubnetResult:
-			for _, s := range subs {
-				if s.MapPublicIpOnLaunch {
-					publicSubnetIDs = append(publicSubnetIDs, pulumi.String(s.Id).ToStringOutput())
-					continue
-				}
+		subnets = append(subnets, DefaultVPCSubnet{
+			AvailabilityZone:   subnet.AvailabilityZone,
+			AvailabilityZoneID: subnet.AvailabilityZoneId,
+			CIDRBlock:          subnet.CidrBlock,
+			ID:                 subnet.Id,
+			Type:               subnetType,
+		})
+	}
 
-				privateSubnetIDs = append(privateSubnetIDs, pulumi.String(s.Id).ToStringOutput())
-			}
+	sort.SliceStable(subnets, compareDefaultVPCSubnets(subnets))
+
+	var availabilityZones []DefaultVPCAvailabilityZone
+	var publicSubnetIDs []string
+	var privateSubnetIDs []string
+	for _, subnet := range subnets {
+		if len(availabilityZones) == 0 || availabilityZones[len(availabilityZones)-1].Name != subnet.AvailabilityZone {
+			availabilityZones = append(availabilityZones, DefaultVPCAvailabilityZone{
+				Name: subnet.AvailabilityZone,
+			})
 		}
 
-		return defaultSubnetOutput{
-			PublicSubnetIDs:  pulumi.ToStringArrayOutput(publicSubnetIDs),
-			PrivateSubnetIDs: pulumi.ToStringArrayOutput(privateSubnetIDs),
+		az := &availabilityZones[len(availabilityZones)-1]
+		az.Subnets = append(az.Subnets, subnet)
+
+		if subnet.IsPublic() {
+			az.PublicSubnetIDs = append(az.PublicSubnetIDs, subnet.ID)
+			publicSubnetIDs = append(publicSubnetIDs, subnet.ID)
+			continue
 		}
-	}).(pulumi.AnyOutput)
+
+		az.PrivateSubnetIDs = append(az.PrivateSubnetIDs, subnet.ID)
+		privateSubnetIDs = append(privateSubnetIDs, subnet.ID)
+	}
 
 	return &DefaultVPCOutput{
-		VPCID: pulumi.String(vpc.Id).ToStringOutput(),
-		PublicSubnetIDs: subnetValues.ApplyT(func(v interface{}) pulumi.StringArrayOutput {
-			so := v.(defaultSubnetOutput)
-			return so.PublicSubnetIDs
-		}).(pulumi.StringArrayOutput),
-		PrivateSubnetIDs: subnetValues.ApplyT(func(v interface{}) pulumi.StringArrayOutput {
-			so := v.(defaultSubnetOutput)
-			return so.PrivateSubnetIDs
-		}).(pulumi.StringArrayOutput),
+		AvailabilityZones: availabilityZones,
+		Subnets:           subnets,
+		VPCID:             pulumi.String(vpc.Id).ToStringOutput(),
+		PublicSubnetIDs:   pulumi.ToStringArray(publicSubnetIDs).ToStringArrayOutput(),
+		PrivateSubnetIDs:  pulumi.ToStringArray(privateSubnetIDs).ToStringArrayOutput(),
 	}, nil
 }
+
+// getPublicRouteTables returns, for every subnet explicitly associated with a route table in the VPC,
+// whether that route table routes to an internet gateway, along with the same for the main route table.
+func getPublicRouteTables(ctx *pulumi.Context, vpcID string, opts ...pulumi.InvokeOption) (map[string]bool, bool, error) {
+	routeTableIDs, err := ec2.GetRouteTables(ctx, &ec2.GetRouteTablesArgs{
+		VpcId: pulumi.StringRef(vpcID),
+	}, opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	subnets := map[string]bool{}
+	mainIsPublic := false
+	for _, id := range routeTableIDs.Ids {
+		routeTable, err := ec2.LookupRouteTable(ctx, &ec2.LookupRouteTableArgs{
+			RouteTableId: pulumi.StringRef(id),
+		}, opts...)
+		if err != nil {
+			return nil, false, err
+		}
+
+		isPublic := hasInternetGatewayDefaultRoute(routeTable.Routes)
+		for _, association := range routeTable.Associations {
+			if association.Main {
+				mainIsPublic = isPublic
+			}
+
+			if association.SubnetId != "" {
+				subnets[association.SubnetId] = isPublic
+			}
+		}
+	}
+
+	return subnets, mainIsPublic, nil
+}
+
+func hasInternetGatewayDefaultRoute(routes []ec2.GetRouteTableRoute) bool {
+	for _, route := range routes {
+		isDefaultRoute := route.CidrBlock == "0.0.0.0/0" || route.Ipv6CidrBlock == "::/0"
+		if isDefaultRoute && strings.HasPrefix(route.GatewayId, "igw-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compareDefaultVPCSubnets(subnets []DefaultVPCSubnet) func(x, y int) bool {
+	return func(x, y int) bool {
+		subnet1 := subnets[x]
+		subnet2 := subnets[y]
+
+		if subnet1.AvailabilityZone != subnet2.AvailabilityZone {
+			return subnet1.AvailabilityZone < subnet2.AvailabilityZone
+		}
+
+		if subnet1.Type != subnet2.Type {
+			return subnet1.IsPublic()
+		}
+
+		return compareCIDRBlocks(subnet1.CIDRBlock, subnet2.CIDRBlock)
+	}
+}
@@ -0,0 +1,88 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// instanceNetworkInputs are the network settings of components that launch EC2 instances.
+type instanceNetworkInputs struct {
+	AssociatePublicIPAddress bool
+	Ingress                  ec2.SecurityGroupIngressArrayInput
+	SecurityGroupIDs         []string
+	SubnetIDs                pulumi.StringArrayInput
+	Tags                     map[string]string
+	VpcID                    pulumi.StringInput
+}
+
+type instanceNetworkResult struct {
+	AssociatePublicIPAddress bool
+	SecurityGroup            *ec2.SecurityGroup
+	SecurityGroupIDs         pulumi.StringArrayOutput
+	SubnetIDs                pulumi.StringArrayInput
+}
+
+// instanceNetwork places EC2 instances in the given subnets, or in the public subnets of the default VPC
+// with a public IP, as the default VPC has no NAT to reach AWS endpoints through. Unless security groups
+// are given, a security group allowing all egress and the given ingress is created in the VPC of the
+// subnets.
+func instanceNetwork(ctx *pulumi.Context, name string, inputs *instanceNetworkInputs, opts ...pulumi.ResourceOption) (*instanceNetworkResult, error) {
+	result := &instanceNetworkResult{
+		AssociatePublicIPAddress: inputs.AssociatePublicIPAddress,
+		SecurityGroupIDs:         pulumi.ToStringArray(inputs.SecurityGroupIDs).ToStringArrayOutput(),
+		SubnetIDs:                inputs.SubnetIDs,
+	}
+
+	vpcID := inputs.VpcID
+	if result.SubnetIDs == nil {
+		if vpcID != nil {
+			return nil, fmt.Errorf("[subnetIds] must be specified when [vpcId] is specified")
+		}
+
+		defaultVPC, err := getDefaultVPC(ctx, nil, invokeOptions(opts)...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.AssociatePublicIPAddress = true
+		result.SubnetIDs = defaultVPC.PublicSubnetIDs
+		vpcID = defaultVPC.VPCID
+	}
+
+	if len(inputs.SecurityGroupIDs) > 0 {
+		return result, nil
+	}
+
+	if vpcID == nil {
+		subnet := ec2.LookupSubnetOutput(ctx, ec2.LookupSubnetOutputArgs{
+			Id: result.SubnetIDs.ToStringArrayOutput().Index(pulumi.Int(0)).ToStringPtrOutput(),
+		}, invokeOptions(opts)...)
+
+		vpcID = subnet.VpcId()
+	}
+
+	securityGroup, err := ec2.NewSecurityGroup(ctx, name, &ec2.SecurityGroupArgs{
+		VpcId:   vpcID,
+		Ingress: inputs.Ingress,
+		Egress: ec2.SecurityGroupEgressArray{
+			&ec2.SecurityGroupEgressArgs{
+				FromPort:       pulumi.Int(0),
+				ToPort:         pulumi.Int(0),
+				Protocol:       pulumi.String("-1"),
+				CidrBlocks:     pulumi.ToStringArray([]string{"0.0.0.0/0"}),
+				Ipv6CidrBlocks: pulumi.ToStringArray([]string{"::/0"}),
+			},
+		},
+		Tags: pulumi.ToStringMap(inputs.Tags),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.SecurityGroup = securityGroup
+	result.SecurityGroupIDs = pulumi.ToStringArrayOutput([]pulumi.StringOutput{securityGroup.ID().ToStringOutput()})
+
+	return result, nil
+}
@@ -63,7 +63,8 @@ func (n natGatewayStrategy) ValidateStrategy(subnets []subnetSpec) error {
 	hasPrivateSubnets := false
 
 	for _, subnet := range subnets {
-		if subnet.IsPublic() {
+		// Local Zone and Outpost subnets cannot host a NAT Gateway.
+		if subnet.IsPublic() && !subnet.IsEdge() {
 			hasPublicSubnets = true
 		}
 
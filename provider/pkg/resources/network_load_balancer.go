@@ -100,7 +100,7 @@ func NewNetworkLoadBalancer(ctx *pulumi.Context, name string, args *NetworkLoadB
 		}
 		component.VpcID = pulumi.String(firstSubnet.VpcId).ToStringOutput()
 	} else {
-		defaultVPC, err := getDefaultVPC(ctx, pulumi.Parent(component))
+		defaultVPC, err := getDefaultVPC(ctx, nil, pulumi.Parent(component))
 		if err != nil {
 			return nil, err
 		}
@@ -2,7 +2,9 @@ package resources
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/zchase/pulumi-awsx-go/pkg/utils"
@@ -19,6 +21,10 @@ func defaultExecutionRolePolicyARNs() []string {
 }
 
 func defaultRoleAssumeRolePolicy(ctx *pulumi.Context) (*iam.GetPolicyDocumentResult, error) {
+	return serviceAssumeRolePolicy(ctx, "ecs-tasks.amazonaws.com")
+}
+
+func serviceAssumeRolePolicy(ctx *pulumi.Context, service string) (*iam.GetPolicyDocumentResult, error) {
 	args := &iam.GetPolicyDocumentArgs{
 		Version: pulumi.StringRef("2012-10-17"),
 		Statements: []iam.GetPolicyDocumentStatement{
@@ -27,7 +33,7 @@ func defaultRoleAssumeRolePolicy(ctx *pulumi.Context) (*iam.GetPolicyDocumentRes
 				Principals: []iam.GetPolicyDocumentStatementPrincipal{
 					{
 						Type:        "Service",
-						Identifiers: []string{"ecs-tasks.amazonaws.com"},
+						Identifiers: []string{service},
 					},
 				},
 				Effect: pulumi.StringRef("Allow"),
@@ -39,6 +45,74 @@ func defaultRoleAssumeRolePolicy(ctx *pulumi.Context) (*iam.GetPolicyDocumentRes
 	return iam.GetPolicyDocument(ctx, args)
 }
 
+func allowPolicyDocument(ctx *pulumi.Context, statements []iam.GetPolicyDocumentStatement) (string, error) {
+	for i := range statements {
+		statements[i].Effect = pulumi.StringRef("Allow")
+	}
+
+	policy, err := iam.GetPolicyDocument(ctx, &iam.GetPolicyDocumentArgs{
+		Version:    pulumi.StringRef("2012-10-17"),
+		Statements: statements,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return policy.Json, nil
+}
+
+// awsARNPrefix holds the partition, region and account the ARNs of the resources of a stack start with.
+type awsARNPrefix struct {
+	AccountID string
+	Partition string
+	Region    string
+}
+
+// For returns the prefix of the ARNs of a service, e.g. `arn:aws:logs:us-west-2:123456789012`.
+func (p *awsARNPrefix) For(service string) string {
+	return fmt.Sprintf("arn:%s:%s:%s:%s", p.Partition, service, p.Region, p.AccountID)
+}
+
+// arnPrefix looks up the partition, region and account of the provider the resources are created
+// with, forwarding the parent and provider of the resource options to the invokes.
+func arnPrefix(ctx *pulumi.Context, opts ...pulumi.ResourceOption) (*awsARNPrefix, error) {
+	invokeOpts := invokeOptions(opts)
+
+	partition, err := aws.GetPartition(ctx, invokeOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := aws.GetRegion(ctx, nil, invokeOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	callerIdentity, err := aws.GetCallerIdentity(ctx, invokeOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsARNPrefix{
+		AccountID: callerIdentity.AccountId,
+		Partition: partition.Partition,
+		Region:    region.Name,
+	}, nil
+}
+
+// invokeOptions returns the resource options that also apply to invokes, such as the parent and
+// provider.
+func invokeOptions(opts []pulumi.ResourceOption) []pulumi.InvokeOption {
+	var invokeOpts []pulumi.InvokeOption
+	for _, opt := range opts {
+		if invokeOpt, ok := opt.(pulumi.InvokeOption); ok {
+			invokeOpts = append(invokeOpts, invokeOpt)
+		}
+	}
+
+	return invokeOpts
+}
+
 type RoleWithPolicyInputs struct {
 	Description         string                         `pulumi:"description"`
 	ForceDetachPolicies bool                           `pulumi:"forceDetachPolicies"`
@@ -65,6 +139,23 @@ type defaultRoleWithPoliciesResult struct {
 	Policies []*iam.RolePolicyAttachment
 }
 
+// RoleName returns the name of the role, which is taken from the ARN of an existing role.
+func (r *defaultRoleWithPoliciesResult) RoleName() pulumi.StringOutput {
+	if r.Role != nil {
+		return r.Role.Name
+	}
+
+	return r.RoleARN.ApplyT(func(arn string) (string, error) {
+		parts, err := utils.ParseARN(arn)
+		if err != nil {
+			return "", err
+		}
+
+		pathParts := strings.Split(parts.ResourceID, "/")
+		return pathParts[len(pathParts)-1], nil
+	}).(pulumi.StringOutput)
+}
+
 func defaultRoleWithPolicies(ctx *pulumi.Context, name string, inputs DefaultRoleWithPolicyInputs, assumeRolePolicy string, opts ...pulumi.ResourceOption) (*defaultRoleWithPoliciesResult, error) {
 	if inputs.RoleARN != "" && inputs.Args != nil {
 		return nil, fmt.Errorf("Can't define role args if specified an existing role ARN")
@@ -91,12 +91,12 @@ func getOverlappingSubnets(specs []subnetSpec) ([]subnetSpec, error) {
 				continue
 			}
 
-			hasOverlap, err := doSubnetsOverlap(x, y)
+			overlaps, err := doSubnetsOverlap(x, y)
 			if err != nil {
 				return nil, err
 			}
 
-			if hasOverlap {
+			if overlaps {
 				hasOverlap = true
 			}
 		}
@@ -130,6 +130,20 @@ func ip2BigInt(ip net.IP) *big.Int {
 	return i
 }
 
+func compareCIDRBlocks(cidr1, cidr2 string) bool {
+	_, ip1, err := net.ParseCIDR(cidr1)
+	if err != nil {
+		return cidr1 < cidr2
+	}
+
+	_, ip2, err := net.ParseCIDR(cidr2)
+	if err != nil {
+		return cidr1 < cidr2
+	}
+
+	return ip2BigInt(ip1.IP).Cmp(ip2BigInt(ip2.IP)) < 0
+}
+
 func cidrSubnetV4(ipRange string, newBits, netNum int) (string, error) {
 	_, ip, err := net.ParseCIDR(ipRange)
 	if err != nil {
@@ -138,6 +152,10 @@ func cidrSubnetV4(ipRange string, newBits, netNum int) (string, error) {
 
 	ipSubnetMaskBits, _ := ip.Mask.Size()
 	newSubnetMask := ipSubnetMaskBits + newBits
+	if newBits < 0 {
+		return "", fmt.Errorf("Requested a /%v subnet, which is larger than the /%v block %s it is taken from.", newSubnetMask, ipSubnetMaskBits, ipRange)
+	}
+
 	if newSubnetMask > 32 {
 		return "", fmt.Errorf("Requested %v new bits, but only %v are available.", newBits, 32-ipSubnetMaskBits)
 	}
@@ -196,11 +214,18 @@ func generateDefaultSubnets(vpcName, vpcCidr string, azNames, azBases []string)
 	return append(privateSubnets, publicSubnets...), nil
 }
 
-func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []subnetSpecInput) ([]subnetSpec, error) {
+func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []subnetSpecInput, firewallCIDRMask int) ([]subnetSpec, error) {
+	var edgeSubnetsIn []subnetSpecInput
+	for _, subnetIn := range subnetInputs {
+		if subnetIn.IsEdge() {
+			edgeSubnetsIn = append(edgeSubnetsIn, subnetIn)
+		}
+	}
+
 	newBitsPerAZ := math.Log2(float64(nextPow2(len(azNames))))
 
 	var azBases []string
-	for i := range azNames {
+	for i := 0; i < len(azNames); i++ {
 		azBase, err := cidrSubnetV4(vpcCidr, int(newBitsPerAZ), i)
 		if err != nil {
 			return nil, err
@@ -209,7 +234,12 @@ func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []su
 	}
 
 	if len(subnetInputs) == 0 {
-		return generateDefaultSubnets(vpcName, vpcCidr, azNames, azBases)
+		defaultSubnets, err := generateDefaultSubnets(vpcName, vpcCidr, azNames, azBases)
+		if err != nil {
+			return nil, err
+		}
+
+		return appendFirewallSubnetSpecs(defaultSubnets, vpcName, azNames, azBases, firewallCIDRMask)
 	}
 
 	_, ip, err := net.ParseCIDR(azBases[0])
@@ -217,12 +247,16 @@ func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []su
 		return nil, fmt.Errorf("Error parsing IP range for non default VPC: %v", err)
 	}
 
-	_, baseSubnetMaskBits := ip.Mask.Size()
+	baseSubnetMaskBits, _ := ip.Mask.Size()
 
 	var privateSubnetsIn []subnetSpecInput
 	var publicSubnetsIn []subnetSpecInput
 	var isolatedSubnetsIn []subnetSpecInput
 	for _, subnetIn := range subnetInputs {
+		if subnetIn.IsEdge() {
+			continue
+		}
+
 		if subnetIn.IsPrivate() {
 			privateSubnetsIn = append(privateSubnetsIn, subnetIn)
 		}
@@ -272,7 +306,7 @@ func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []su
 				return nil, err
 			}
 
-			_, basePublicSubnetMaskBits := baseIP.Mask.Size()
+			basePublicSubnetMaskBits, _ := baseIP.Mask.Size()
 
 			splitBase := azBases[i]
 			if len(privateSubnetsOut) > 0 {
@@ -310,7 +344,7 @@ func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []su
 				return nil, err
 			}
 
-			_, baseIsolatedSubnetMaskBits := baseIP.Mask.Size()
+			baseIsolatedSubnetMaskBits, _ := baseIP.Mask.Size()
 
 			splitBase := azBases[i]
 			if (len(publicSubnetsOut) > 0) || (len(privateSubnetsOut) > 0) {
@@ -322,6 +356,10 @@ func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []su
 
 			newIsolatedSubnetBits := isolatedIn.CIDRMask - baseIsolatedSubnetMaskBits
 			isolatedSubnetCidrBlock, err := cidrSubnetV4(splitBase, newIsolatedSubnetBits, j)
+			if err != nil {
+				return nil, err
+			}
+
 			isolatedSubnetsOut = append(isolatedSubnetsOut, subnetSpec{
 				AzName:     name,
 				CidrBlock:  isolatedSubnetCidrBlock,
@@ -335,5 +373,118 @@ func getSubnetSpecs(vpcName, vpcCidr string, azNames []string, subnetInputs []su
 		subnetOuts = append(subnetOuts, isolatedSubnetsOut...)
 	}
 
-	return subnetOuts, nil
+	edgeSubnets, err := getEdgeSubnetSpecs(vpcName, vpcCidr, azBases, edgeSubnetsIn)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetOuts = append(subnetOuts, edgeSubnets...)
+
+	return appendFirewallSubnetSpecs(subnetOuts, vpcName, azNames, azBases, firewallCIDRMask)
+}
+
+// appendFirewallSubnetSpecs adds one Network Firewall subnet per availability zone, taken from the end
+// of the zone's block of the VPC CIDR so that it stays clear of the subnets allocated from its start.
+// Nothing is added when the firewall CIDR mask is 0.
+func appendFirewallSubnetSpecs(specs []subnetSpec, vpcName string, azNames, azBases []string, cidrMask int) ([]subnetSpec, error) {
+	if cidrMask == 0 {
+		return specs, nil
+	}
+
+	for i, name := range azNames {
+		_, baseIP, err := net.ParseCIDR(azBases[i])
+		if err != nil {
+			return nil, err
+		}
+
+		baseMaskBits, _ := baseIP.Mask.Size()
+		newBits := cidrMask - baseMaskBits
+		if newBits < 0 {
+			return nil, fmt.Errorf("The firewall [cidrMask] /%v is larger than the /%v block of availability zone %s", cidrMask, baseMaskBits, name)
+		}
+
+		cidrBlock, err := cidrSubnetV4(azBases[i], newBits, (1<<newBits)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		specs = append(specs, subnetSpec{
+			AzName:     name,
+			CidrBlock:  cidrBlock,
+			Type:       "Firewall",
+			SubnetName: fmt.Sprintf("%s-firewall-%v", vpcName, i+1),
+		})
+	}
+
+	return specs, nil
+}
+
+// getEdgeSubnetSpecs allocates the Local Zone and Outpost subnets, in order, from the space of the VPC
+// CIDR left after the blocks of the availability zones, each aligned to its own size. The blocks of the
+// availability zones are left as they would be without them.
+func getEdgeSubnetSpecs(vpcName, vpcCidr string, azBases []string, subnetsIn []subnetSpecInput) ([]subnetSpec, error) {
+	if len(subnetsIn) == 0 {
+		return nil, nil
+	}
+
+	_, vpcIP, err := net.ParseCIDR(vpcCidr)
+	if err != nil {
+		return nil, err
+	}
+
+	vpcMaskBits, _ := vpcIP.Mask.Size()
+	vpcStart := ip2BigInt(vpcIP.IP.To4()).Uint64()
+	vpcEnd := vpcStart + (uint64(1) << (32 - vpcMaskBits))
+
+	_, lastAZIP, err := net.ParseCIDR(azBases[len(azBases)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	azMaskBits, _ := lastAZIP.Mask.Size()
+	next := ip2BigInt(lastAZIP.IP.To4()).Uint64() + (uint64(1) << (32 - azMaskBits))
+
+	var result []subnetSpec
+	for _, subnetIn := range subnetsIn {
+		if subnetIn.LocalZoneName != "" && subnetIn.OutpostArn != "" {
+			return nil, fmt.Errorf("Only one of [localZoneName] or [outpostArn] can be specified for subnet %s", subnetIn.Name)
+		}
+
+		if subnetIn.CIDRMask == 0 {
+			return nil, fmt.Errorf("Subnet %s: [cidrMask] is required for Local Zone and Outpost subnets", subnetIn.Name)
+		}
+
+		if subnetIn.CIDRMask < vpcMaskBits || subnetIn.CIDRMask > 32 {
+			return nil, fmt.Errorf("Subnet %s: [cidrMask] /%v does not fit in the VPC CIDR %s", subnetIn.Name, subnetIn.CIDRMask, vpcCidr)
+		}
+
+		size := uint64(1) << (32 - subnetIn.CIDRMask)
+		start := (next + size - 1) / size * size
+		if start+size > vpcEnd {
+			return nil, fmt.Errorf("Subnet %s: there is no room for a /%v block in the VPC CIDR %s after the blocks of the availability zones. Make the CIDR for the VPC larger, use less Availability Zones, or reduce the [cidrMask] of the subnet", subnetIn.Name, subnetIn.CIDRMask, vpcCidr)
+		}
+		next = start + size
+
+		cidrBlock := fmt.Sprintf("%s/%v", net.IPv4(byte(start>>24), byte(start>>16), byte(start>>8), byte(start)).String(), subnetIn.CIDRMask)
+
+		subnetType := "Private"
+		if subnetIn.IsPublic() {
+			subnetType = "Public"
+		} else if subnetIn.IsIsolated() {
+			subnetType = "Isolated"
+		}
+
+		// The availability zone of an Outpost subnet is the zone its Outpost is anchored to, which is
+		// resolved when the VPC is created.
+		result = append(result, subnetSpec{
+			AzName:        subnetIn.LocalZoneName,
+			CidrBlock:     cidrBlock,
+			Type:          subnetType,
+			SubnetName:    fmt.Sprintf("%s-%s", vpcName, subnetIn.Name),
+			LocalZoneName: subnetIn.LocalZoneName,
+			OutpostArn:    subnetIn.OutpostArn,
+		})
+	}
+
+	return result, nil
 }
@@ -0,0 +1,210 @@
+package resources
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func subnetCIDRBlocks(specs []subnetSpec) map[string]string {
+	result := map[string]string{}
+	for _, spec := range specs {
+		result[spec.SubnetName] = spec.CidrBlock
+	}
+
+	return result
+}
+
+func TestGetSubnetSpecs(t *testing.T) {
+	azNames := []string{"us-west-2a", "us-west-2b", "us-west-2c"}
+	subnets := []subnetSpecInput{
+		{Name: "private", Type: "Private", CIDRMask: 19},
+		{Name: "public", Type: "Public", CIDRMask: 20},
+	}
+
+	specs, err := getSubnetSpecs("vpc", "10.0.0.0/16", azNames, subnets, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"vpc-private-1": "10.0.0.0/19",
+		"vpc-public-1":  "10.0.32.0/20",
+		"vpc-private-2": "10.0.64.0/19",
+		"vpc-public-2":  "10.0.96.0/20",
+		"vpc-private-3": "10.0.128.0/19",
+		"vpc-public-3":  "10.0.160.0/20",
+	}
+	if actual := subnetCIDRBlocks(specs); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected subnets %v, got %v", expected, actual)
+	}
+
+	// Local Zone and Outpost subnets take the space after the blocks of the availability zones, which
+	// are left as they are.
+	edgeSubnets := append(subnets,
+		subnetSpecInput{Name: "lz", Type: "Private", CIDRMask: 24, LocalZoneName: "us-west-2-lax-1a"},
+		subnetSpecInput{Name: "outpost", Type: "Public", CIDRMask: 22, OutpostArn: "arn:aws:outposts:us-west-2:123456789012:outpost/op-0123456789abcdef0"},
+	)
+
+	specs, err = getSubnetSpecs("vpc", "10.0.0.0/16", azNames, edgeSubnets, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected["vpc-lz"] = "10.0.192.0/24"
+	expected["vpc-outpost"] = "10.0.196.0/22"
+	if actual := subnetCIDRBlocks(specs); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected subnets %v, got %v", expected, actual)
+	}
+
+	if err := validateSubnets(specs); err != nil {
+		t.Errorf("expected the subnets not to overlap: %v", err)
+	}
+}
+
+func TestGetSubnetSpecsRejectsEdgeSubnets(t *testing.T) {
+	cases := map[string]struct {
+		azNames []string
+		subnet  subnetSpecInput
+		problem string
+	}{
+		"without a cidrMask": {
+			azNames: []string{"us-west-2a", "us-west-2b", "us-west-2c"},
+			subnet:  subnetSpecInput{Name: "lz", Type: "Private", LocalZoneName: "us-west-2-lax-1a"},
+			problem: "[cidrMask] is required",
+		},
+		"without room after the availability zones": {
+			azNames: []string{"us-west-2a", "us-west-2b"},
+			subnet:  subnetSpecInput{Name: "lz", Type: "Private", CIDRMask: 24, LocalZoneName: "us-west-2-lax-1a"},
+			problem: "there is no room for a /24 block",
+		},
+		"in a Local Zone and on an Outpost": {
+			azNames: []string{"us-west-2a", "us-west-2b", "us-west-2c"},
+			subnet:  subnetSpecInput{Name: "lz", Type: "Private", CIDRMask: 24, LocalZoneName: "us-west-2-lax-1a", OutpostArn: "arn"},
+			problem: "Only one of [localZoneName] or [outpostArn]",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := getSubnetSpecs("vpc", "10.0.0.0/16", c.azNames, []subnetSpecInput{
+				{Name: "private", Type: "Private", CIDRMask: 19},
+				c.subnet,
+			}, 0)
+			if err == nil || !strings.Contains(err.Error(), c.problem) {
+				t.Errorf("expected an error containing %q, got %v", c.problem, err)
+			}
+		})
+	}
+}
+
+func TestCompareCIDRBlocks(t *testing.T) {
+	cases := []struct {
+		cidr1, cidr2 string
+		less         bool
+	}{
+		{"10.0.2.0/24", "10.0.10.0/24", true},
+		{"10.0.10.0/24", "10.0.2.0/24", false},
+		{"10.0.0.0/16", "10.0.0.0/24", false},
+		{"9.255.0.0/16", "10.0.0.0/16", true},
+		{"invalid-a", "invalid-b", true},
+	}
+
+	for _, c := range cases {
+		if less := compareCIDRBlocks(c.cidr1, c.cidr2); less != c.less {
+			t.Errorf("expected compareCIDRBlocks(%s, %s) to be %v", c.cidr1, c.cidr2, c.less)
+		}
+	}
+}
+
+func TestGetOverlappingSubnets(t *testing.T) {
+	specs := []subnetSpec{
+		{SubnetName: "a", CidrBlock: "10.0.0.0/24"},
+		{SubnetName: "b", CidrBlock: "10.0.0.128/25"},
+		{SubnetName: "c", CidrBlock: "10.0.1.0/24"},
+	}
+
+	overlapping, err := getOverlappingSubnets(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, spec := range overlapping {
+		names = append(names, spec.SubnetName)
+	}
+
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected subnets %v to overlap, got %v", expected, names)
+	}
+
+	if err := validateSubnets(specs); err == nil {
+		t.Error("expected the overlapping subnets to be rejected")
+	}
+}
+
+func TestAppendFirewallSubnetSpecs(t *testing.T) {
+	azNames := []string{"us-west-2a", "us-west-2b", "us-west-2c"}
+
+	specs, err := getSubnetSpecs("vpc", "10.0.0.0/16", azNames, nil, 28)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := map[string]string{}
+	for _, spec := range specs {
+		if spec.IsFirewall() {
+			actual[spec.SubnetName] = spec.CidrBlock
+		}
+	}
+
+	// Firewall subnets are taken from the end of the block of their availability zone.
+	expected := map[string]string{
+		"vpc-firewall-1": "10.0.63.240/28",
+		"vpc-firewall-2": "10.0.127.240/28",
+		"vpc-firewall-3": "10.0.191.240/28",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected firewall subnets %v, got %v", expected, actual)
+	}
+
+	if err := validateSubnets(specs); err != nil {
+		t.Errorf("expected the firewall subnets not to overlap: %v", err)
+	}
+
+	// A subnet reaching into the end of its zone's block collides with the firewall subnet.
+	specs, err = getSubnetSpecs("vpc", "10.0.0.0/16", azNames, []subnetSpecInput{
+		{Name: "private", Type: "Private", CIDRMask: 18},
+	}, 28)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateSubnets(specs); err == nil {
+		t.Error("expected the firewall subnets to overlap the private subnets")
+	}
+
+	if _, err := appendFirewallSubnetSpecs(nil, "vpc", azNames[:1], []string{"10.0.0.0/18"}, 16); err == nil {
+		t.Error("expected a firewall cidrMask larger than the zone's block to be rejected")
+	}
+}
+
+func TestValidateFirewallRejectsPublicEdgeSubnets(t *testing.T) {
+	inputs := &vpcFirewallInput{AllowedDomains: []string{".example.com"}}
+
+	err := validateFirewall(inputs, []subnetSpec{
+		{SubnetName: "vpc-public-1", Type: "Public", AzName: "us-west-2a"},
+		{SubnetName: "vpc-lz", Type: "Public", LocalZoneName: "us-west-2-lax-1a"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "vpc-lz") {
+		t.Errorf("expected the public Local Zone subnet to be rejected, got %v", err)
+	}
+
+	err = validateFirewall(inputs, []subnetSpec{
+		{SubnetName: "vpc-public-1", Type: "Public", AzName: "us-west-2a"},
+		{SubnetName: "vpc-lz", Type: "Private", LocalZoneName: "us-west-2-lax-1a"},
+	})
+	if err != nil {
+		t.Errorf("expected private Local Zone subnets to be allowed, got %v", err)
+	}
+}
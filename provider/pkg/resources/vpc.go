@@ -16,7 +16,6 @@ package resources
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
@@ -48,7 +47,20 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 			desiredCount = 3
 		}
 
-		azs, err := aws.GetAvailabilityZones(ctx, &aws.GetAvailabilityZonesArgs{})
+		// Local Zones and Wavelength Zones are only used for subnets that explicitly ask for them.
+		azs, err := aws.GetAvailabilityZones(ctx, &aws.GetAvailabilityZonesArgs{
+			State: pulumi.StringRef("available"),
+			Filters: []aws.GetAvailabilityZonesFilter{
+				{
+					Name:   "opt-in-status",
+					Values: []string{"opt-in-not-required"},
+				},
+				{
+					Name:   "zone-type",
+					Values: []string{"availability-zone"},
+				},
+			},
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -76,7 +88,20 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 		cidrBlock = "10.0.0.0/16"
 	}
 
-	subnetSpecs, err := getSubnetSpecs(name, cidrBlock, availabilityZones, args.SubnetSpecs)
+	firewallCIDRMask := 0
+	if args.Firewall != nil {
+		firewallCIDRMask = args.Firewall.CIDRMask
+		if firewallCIDRMask == 0 {
+			firewallCIDRMask = defaultFirewallSubnetCIDRMask
+		}
+	}
+
+	subnetSpecs, err := getSubnetSpecs(name, cidrBlock, availabilityZones, args.SubnetSpecs, firewallCIDRMask)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetSpecs, err = resolveEdgeSubnetSpecs(ctx, subnetSpecs)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +116,31 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 		return nil, err
 	}
 
+	err = validateFirewall(args.Firewall, subnetSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateSubnetSharing(args.SubnetSharing)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultResourcesMode := defaultResourcesMode(args.DefaultResourcesMode)
+	if defaultResourcesMode == "" {
+		defaultResourcesMode = "Secure"
+	}
+
+	err = defaultResourcesMode.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = validatePrivateHostedZone(args)
+	if err != nil {
+		return nil, err
+	}
+
 	vpcTags := map[string]string{
 		"Name": name,
 	}
@@ -149,11 +199,33 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 		return nil, err
 	}
 
+	component.PrivateHostedZoneID = pulumi.String("").ToStringOutput()
+	if args.PrivateHostedZone != nil {
+		privateHostedZone, err := vpcPrivateHostedZone(ctx, name, args.PrivateHostedZone, vpcId, vpcChildResourceOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.PrivateHostedZone = privateHostedZone.Zone
+		component.PrivateHostedZoneID = privateHostedZone.ZoneID
+	}
+
+	if args.DHCPOptions != nil {
+		dhcpOptions, err := vpcDHCPOptions(ctx, name, args.DHCPOptions, vpcId, vpcChildResourceOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.DHCPOptions = dhcpOptions
+	}
+
 	var vpcEndpoints []*ec2.VpcEndpoint
 	var subnets []*ec2.Subnet
 	var routeTables []*ec2.RouteTable
 	var routeTableAssociations []*ec2.RouteTableAssociation
 	var routes []*ec2.Route
+	var firewallSubnetIds []pulumi.IDOutput
+	var sharedSubnets []*ec2.Subnet
 	var natGateways []*ec2.NatGateway
 	var eips []*ec2.Eip
 	var publicSubnetIds []pulumi.IDOutput
@@ -180,18 +252,29 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 		vpcEndpoints = append(vpcEndpoints, vpcEndpoint)
 	}
 
-	for i, zone := range availabilityZones {
-		var specs []subnetSpec
-		for _, spec := range subnetSpecs {
-			if spec.AzName == zone {
-				specs = append(specs, spec)
-			}
+	var firewall *vpcFirewallResult
+	if args.Firewall != nil {
+		firewall, err = vpcFirewall(ctx, name, args.Firewall, subnetSpecs, vpcId, igw, vpcChildResourceOptions...)
+		if err != nil {
+			return nil, err
 		}
 
-		sort.SliceStable(specs, compareSubnetSpecs(specs))
+		subnets = append(subnets, firewall.Subnets...)
+		routeTables = append(routeTables, firewall.RouteTables...)
+		routeTableAssociations = append(routeTableAssociations, firewall.RouteTableAssociations...)
+		routes = append(routes, firewall.Routes...)
+		firewallSubnetIds = firewall.SubnetIDs
+
+		component.Firewall = firewall.Firewall
+		component.FirewallPolicy = firewall.FirewallPolicy
+		component.FirewallRuleGroup = firewall.RuleGroup
+	}
+
+	for _, group := range getSubnetGroups(availabilityZones, subnetSpecs) {
+		i := group.AzIndex
 
-		for _, spec := range specs {
-			subnet, err := ec2.NewSubnet(ctx, spec.SubnetName, &ec2.SubnetArgs{
+		for _, spec := range group.Specs {
+			subnetArgs := &ec2.SubnetArgs{
 				VpcId:               vpcId,
 				AvailabilityZone:    pulumi.Sprintf("%s", spec.AzName),
 				MapPublicIpOnLaunch: pulumi.BoolPtr(strings.ToLower(spec.Type) == "public"),
@@ -199,13 +282,23 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 				Tags: pulumi.ToStringMap(map[string]string{
 					"Name": spec.SubnetName,
 				}),
-			}, vpcChildResourceOptions...)
+			}
+
+			if spec.OutpostArn != "" {
+				subnetArgs.OutpostArn = pulumi.StringPtr(spec.OutpostArn)
+			}
+
+			subnet, err := ec2.NewSubnet(ctx, spec.SubnetName, subnetArgs, vpcChildResourceOptions...)
 			if err != nil {
 				return nil, err
 			}
 
 			subnets = append(subnets, subnet)
 
+			if args.SubnetSharing != nil && args.SubnetSharing.Shares(spec) {
+				sharedSubnets = append(sharedSubnets, subnet)
+			}
+
 			if spec.IsPublic() {
 				publicSubnetIds = append(publicSubnetIds, subnet.ID())
 			}
@@ -245,7 +338,9 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 				return nil, err
 			}
 
-			if spec.IsPublic() && createNatGateway {
+			// NAT Gateways are not available in Local Zones or on Outposts, so private subnets there route
+			// through the NAT Gateway of their parent availability zone instead.
+			if spec.IsPublic() && createNatGateway && !group.IsEdge {
 				createEip := len(allocationIds) == 0
 
 				var natGatewayAllocationIDs pulumi.StringOutput
@@ -277,7 +372,32 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 				natGateways = append(natGateways, natGateway)
 			}
 
-			if spec.IsPublic() {
+			if spec.IsPublic() && firewall != nil && !group.IsEdge {
+				// Traffic between the public subnet and the Internet Gateway goes through the firewall
+				// endpoint of the subnet's availability zone in both directions.
+				endpointID := firewall.EndpointID(spec.AzName)
+
+				route, err := ec2.NewRoute(ctx, spec.SubnetName, &ec2.RouteArgs{
+					RouteTableId:         routeTable.ID(),
+					VpcEndpointId:        endpointID,
+					DestinationCidrBlock: pulumi.String("0.0.0.0/0"),
+				}, pulumi.Parent(routeTable), pulumi.DependsOn([]pulumi.Resource{routeTable, firewall.Firewall}))
+				if err != nil {
+					return nil, err
+				}
+				routes = append(routes, route)
+
+				ingressRouteName := fmt.Sprintf("%s-ingress", spec.SubnetName)
+				ingressRoute, err := ec2.NewRoute(ctx, ingressRouteName, &ec2.RouteArgs{
+					RouteTableId:         firewall.EdgeRouteTable.ID(),
+					VpcEndpointId:        endpointID,
+					DestinationCidrBlock: pulumi.String(spec.CidrBlock),
+				}, pulumi.Parent(firewall.EdgeRouteTable), pulumi.DependsOn([]pulumi.Resource{firewall.EdgeRouteTable, firewall.Firewall}))
+				if err != nil {
+					return nil, err
+				}
+				routes = append(routes, ingressRoute)
+			} else if spec.IsPublic() {
 				route, err := ec2.NewRoute(ctx, spec.SubnetName, &ec2.RouteArgs{
 					RouteTableId:         routeTable.ID(),
 					GatewayId:            igw.ID(),
@@ -310,6 +430,33 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 		}
 	}
 
+	component.ResourceShareARN = pulumi.String("").ToStringOutput()
+	if args.SubnetSharing != nil {
+		resourceShare, err := vpcSubnetShare(ctx, name, args.SubnetSharing, sharedSubnets, vpcChildResourceOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.ResourceShare = resourceShare
+		component.ResourceShareARN = resourceShare.Arn
+	}
+
+	component.DefaultSecurityGroupID = vpc.DefaultSecurityGroupId
+	component.DefaultNetworkACLID = vpc.DefaultNetworkAclId
+
+	if defaultResourcesMode.IsSecure() {
+		defaultResources, err := secureVpcDefaultResources(ctx, name, vpc, subnets, vpcChildResourceOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		component.DefaultSecurityGroup = defaultResources.DefaultSecurityGroup
+		component.DefaultNetworkACL = defaultResources.DefaultNetworkACL
+		component.NetworkACL = defaultResources.NetworkACL
+		component.DefaultSecurityGroupID = defaultResources.DefaultSecurityGroup.ID().ToStringOutput()
+		component.DefaultNetworkACLID = defaultResources.DefaultNetworkACL.ID().ToStringOutput()
+	}
+
 	component.EIPS = eips
 	component.InternetGateway = igw
 	component.NatGateways = natGateways
@@ -323,6 +470,7 @@ func NewVPC(ctx *pulumi.Context, name string, args *VPCArgs, opts ...pulumi.Reso
 	component.PublicSubnetIDs = pulumi.ToIDArrayOutput(publicSubnetIds)
 	component.PrivateSubnetIDs = pulumi.ToIDArrayOutput(privateSubnetIds)
 	component.IsolatedSubnetIDs = pulumi.ToIDArrayOutput(isolatedSubnetIds)
+	component.FirewallSubnetIDs = pulumi.ToIDArrayOutput(firewallSubnetIds)
 
 	return component, nil
 }
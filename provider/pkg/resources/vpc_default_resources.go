@@ -0,0 +1,125 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	defaultResourcesModeSecure    = "secure"
+	defaultResourcesModeUnmanaged = "unmanaged"
+)
+
+type defaultResourcesMode string
+
+func (d defaultResourcesMode) ToLower() string {
+	return strings.ToLower(string(d))
+}
+
+func (d defaultResourcesMode) IsSecure() bool {
+	return d.ToLower() == defaultResourcesModeSecure
+}
+
+func (d defaultResourcesMode) IsUnmanaged() bool {
+	return d.ToLower() == defaultResourcesModeUnmanaged
+}
+
+func (d defaultResourcesMode) Validate() error {
+	switch d.ToLower() {
+	case defaultResourcesModeSecure, defaultResourcesModeUnmanaged:
+		return nil
+	default:
+		return fmt.Errorf("Unknown default resources mode %s", d)
+	}
+}
+
+type vpcDefaultResources struct {
+	DefaultSecurityGroup *ec2.DefaultSecurityGroup
+	DefaultNetworkACL    *ec2.DefaultNetworkAcl
+	NetworkACL           *ec2.NetworkAcl
+}
+
+// secureVpcDefaultResources adopts the default security group and network ACL that AWS creates with
+// every VPC and removes all of their rules. Since stripping the default network ACL would otherwise
+// cut off every subnet that is implicitly associated with it, the VPC's subnets are moved to a
+// dedicated network ACL that keeps the AWS default allow-all behavior.
+func secureVpcDefaultResources(ctx *pulumi.Context, name string, vpc *ec2.Vpc, subnets []*ec2.Subnet, opts ...pulumi.ResourceOption) (*vpcDefaultResources, error) {
+	defaultSecurityGroup, err := ec2.NewDefaultSecurityGroup(ctx, name, &ec2.DefaultSecurityGroupArgs{
+		VpcId: vpc.ID(),
+		Tags: pulumi.ToStringMap(map[string]string{
+			"Name": fmt.Sprintf("%s-default", name),
+		}),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultNetworkACL, err := ec2.NewDefaultNetworkAcl(ctx, name, &ec2.DefaultNetworkAclArgs{
+		DefaultNetworkAclId: vpc.DefaultNetworkAclId,
+		Tags: pulumi.ToStringMap(map[string]string{
+			"Name": fmt.Sprintf("%s-default", name),
+		}),
+	}, append(opts, pulumi.IgnoreChanges([]string{"subnetIds"}))...)
+	if err != nil {
+		return nil, err
+	}
+
+	var subnetIDs pulumi.StringArray
+	for _, subnet := range subnets {
+		subnetIDs = append(subnetIDs, subnet.ID().ToStringOutput())
+	}
+
+	networkACL, err := ec2.NewNetworkAcl(ctx, name, &ec2.NetworkAclArgs{
+		VpcId:     vpc.ID(),
+		SubnetIds: subnetIDs,
+		Ingress: ec2.NetworkAclIngressArray{
+			&ec2.NetworkAclIngressArgs{
+				RuleNo:    pulumi.Int(100),
+				Action:    pulumi.String("allow"),
+				Protocol:  pulumi.String("-1"),
+				FromPort:  pulumi.Int(0),
+				ToPort:    pulumi.Int(0),
+				CidrBlock: pulumi.String("0.0.0.0/0"),
+			},
+		},
+		Egress: ec2.NetworkAclEgressArray{
+			&ec2.NetworkAclEgressArgs{
+				RuleNo:    pulumi.Int(100),
+				Action:    pulumi.String("allow"),
+				Protocol:  pulumi.String("-1"),
+				FromPort:  pulumi.Int(0),
+				ToPort:    pulumi.Int(0),
+				CidrBlock: pulumi.String("0.0.0.0/0"),
+			},
+		},
+		Tags: pulumi.ToStringMap(map[string]string{
+			"Name": name,
+		}),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vpcDefaultResources{
+		DefaultSecurityGroup: defaultSecurityGroup,
+		DefaultNetworkACL:    defaultNetworkACL,
+		NetworkACL:           networkACL,
+	}, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func validatePrivateHostedZone(args *VPCArgs) error {
+	zone := args.PrivateHostedZone
+	if zone == nil {
+		return nil
+	}
+
+	if zone.Name != "" && zone.ZoneID != "" {
+		return fmt.Errorf("Only one of [privateHostedZone] [name] or [zoneId] can be specified")
+	}
+
+	if zone.Name == "" && zone.ZoneID == "" {
+		return fmt.Errorf("One of [privateHostedZone] [name] or [zoneId] must be specified")
+	}
+
+	if !args.EnableDNSHostnames || !args.EnableDNSSuport {
+		return fmt.Errorf("A private hosted zone requires both [enableDnsHostnames] and [enableDnsSupport] to be enabled")
+	}
+
+	return nil
+}
+
+type privateHostedZoneResult struct {
+	Zone   *route53.Zone
+	ZoneID pulumi.StringOutput
+}
+
+func vpcPrivateHostedZone(ctx *pulumi.Context, name string, inputs *privateHostedZoneInput, vpcID pulumi.IDOutput, opts ...pulumi.ResourceOption) (*privateHostedZoneResult, error) {
+	if inputs.ZoneID != "" {
+		_, err := route53.NewZoneAssociation(ctx, name, &route53.ZoneAssociationArgs{
+			VpcId:  vpcID,
+			ZoneId: pulumi.String(inputs.ZoneID),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &privateHostedZoneResult{
+			ZoneID: pulumi.String(inputs.ZoneID).ToStringOutput(),
+		}, nil
+	}
+
+	var comment pulumi.StringPtrInput
+	if inputs.Comment != "" {
+		comment = pulumi.StringPtr(inputs.Comment)
+	}
+
+	zone, err := route53.NewZone(ctx, name, &route53.ZoneArgs{
+		Name:         pulumi.StringPtr(inputs.Name),
+		Comment:      comment,
+		ForceDestroy: pulumi.BoolPtr(inputs.ForceDestroy),
+		Tags:         pulumi.ToStringMap(inputs.Tags),
+		Vpcs: route53.ZoneVpcArray{
+			&route53.ZoneVpcArgs{
+				VpcId: vpcID,
+			},
+		},
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &privateHostedZoneResult{
+		Zone:   zone,
+		ZoneID: zone.ZoneId,
+	}, nil
+}
+
+func vpcDHCPOptions(ctx *pulumi.Context, name string, inputs *dhcpOptionsInput, vpcID pulumi.IDOutput, opts ...pulumi.ResourceOption) (*ec2.VpcDhcpOptions, error) {
+	tags := map[string]string{
+		"Name": name,
+	}
+	for tagKey, tagValue := range inputs.Tags {
+		tags[tagKey] = tagValue
+	}
+
+	dhcpOptionsArgs := &ec2.VpcDhcpOptionsArgs{
+		DomainNameServers:  pulumi.ToStringArray(inputs.DomainNameServers),
+		NetbiosNameServers: pulumi.ToStringArray(inputs.NetbiosNameServers),
+		NtpServers:         pulumi.ToStringArray(inputs.NtpServers),
+		Tags:               pulumi.ToStringMap(tags),
+	}
+
+	if inputs.DomainName != "" {
+		dhcpOptionsArgs.DomainName = pulumi.StringPtr(inputs.DomainName)
+	}
+
+	if inputs.NetbiosNodeType != "" {
+		dhcpOptionsArgs.NetbiosNodeType = pulumi.StringPtr(inputs.NetbiosNodeType)
+	}
+
+	dhcpOptions, err := ec2.NewVpcDhcpOptions(ctx, name, dhcpOptionsArgs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ec2.NewVpcDhcpOptionsAssociation(ctx, name, &ec2.VpcDhcpOptionsAssociationArgs{
+		VpcId:         vpcID,
+		DhcpOptionsId: dhcpOptions.ID(),
+	}, pulumi.Parent(dhcpOptions), pulumi.DependsOn([]pulumi.Resource{dhcpOptions}))
+	if err != nil {
+		return nil, err
+	}
+
+	return dhcpOptions, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/outposts"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// resolveEdgeSubnetSpecs looks up the zone of every Local Zone and Outpost subnet, making sure Local
+// Zones have been opted into, and records the parent availability zone used to route their private
+// traffic through a NAT Gateway.
+func resolveEdgeSubnetSpecs(ctx *pulumi.Context, specs []subnetSpec) ([]subnetSpec, error) {
+	var result []subnetSpec
+	for _, spec := range specs {
+		if spec.LocalZoneName != "" {
+			zone, err := aws.GetAvailabilityZone(ctx, &aws.GetAvailabilityZoneArgs{
+				AllAvailabilityZones: pulumi.BoolRef(true),
+				Name:                 pulumi.StringRef(spec.LocalZoneName),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if zone.ZoneType != "local-zone" {
+				return nil, fmt.Errorf("Subnet %s: %s is not a Local Zone", spec.SubnetName, spec.LocalZoneName)
+			}
+
+			if zone.OptInStatus != "opted-in" {
+				return nil, fmt.Errorf("Subnet %s: the account has not opted into Local Zone %s (zone group %s)", spec.SubnetName, spec.LocalZoneName, zone.GroupName)
+			}
+
+			spec.AzName = zone.Name
+			spec.ParentAzName = zone.ParentZoneName
+		}
+
+		if spec.OutpostArn != "" {
+			outpost, err := outposts.GetOutpost(ctx, &outposts.GetOutpostArgs{
+				Arn: pulumi.StringRef(spec.OutpostArn),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			spec.AzName = outpost.AvailabilityZone
+			spec.ParentAzName = outpost.AvailabilityZone
+		}
+
+		result = append(result, spec)
+	}
+
+	return result, nil
+}
+
+type subnetGroup struct {
+	// AzIndex is the index of the availability zone, or of the parent availability zone for Local
+	// Zone and Outpost subnets, whose NAT Gateway the private subnets of the group route through.
+	AzIndex int
+	IsEdge  bool
+	Specs   []subnetSpec
+}
+
+// getSubnetGroups groups the subnets by availability zone, in the order of the given zones, followed
+// by one group for every Local Zone or Outpost subnet. Network Firewall subnets are created along with
+// the firewall and are left out.
+func getSubnetGroups(availabilityZones []string, specs []subnetSpec) []subnetGroup {
+	var groups []subnetGroup
+	for i, zone := range availabilityZones {
+		var zoneSpecs []subnetSpec
+		for _, spec := range specs {
+			if !spec.IsEdge() && !spec.IsFirewall() && spec.AzName == zone {
+				zoneSpecs = append(zoneSpecs, spec)
+			}
+		}
+
+		sort.SliceStable(zoneSpecs, compareSubnetSpecs(zoneSpecs))
+
+		groups = append(groups, subnetGroup{
+			AzIndex: i,
+			Specs:   zoneSpecs,
+		})
+	}
+
+	for _, spec := range specs {
+		if !spec.IsEdge() {
+			continue
+		}
+
+		azIndex := 0
+		for i, zone := range availabilityZones {
+			if zone == spec.ParentAzName {
+				azIndex = i
+			}
+		}
+
+		groups = append(groups, subnetGroup{
+			AzIndex: azIndex,
+			IsEdge:  true,
+			Specs:   []subnetSpec{spec},
+		})
+	}
+
+	return groups
+}
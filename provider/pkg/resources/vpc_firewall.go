@@ -0,0 +1,243 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/networkfirewall"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const defaultFirewallSubnetCIDRMask = 28
+
+func validateFirewall(inputs *vpcFirewallInput, specs []subnetSpec) error {
+	if inputs == nil {
+		return nil
+	}
+
+	if inputs.FirewallPolicyArn != "" && len(inputs.AllowedDomains) > 0 {
+		return fmt.Errorf("Only one of [firewall] [firewallPolicyArn] or [allowedDomains] can be specified")
+	}
+
+	if inputs.FirewallPolicyArn == "" && len(inputs.AllowedDomains) == 0 {
+		return fmt.Errorf("One of [firewall] [firewallPolicyArn] or [allowedDomains] must be specified")
+	}
+
+	// The ingress routes of the edge route table can only target firewall endpoints in the availability
+	// zones of the firewall, which leaves no symmetric path for public Local Zone and Outpost subnets.
+	for _, spec := range specs {
+		if spec.IsPublic() && spec.IsEdge() {
+			return fmt.Errorf("A firewall cannot inspect the traffic of public subnet %s in a Local Zone or on an Outpost, make the subnet private or remove the firewall", spec.SubnetName)
+		}
+	}
+
+	for _, spec := range specs {
+		if spec.IsPublic() && !spec.IsEdge() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("A firewall requires public subnets to inspect the traffic between them and the Internet Gateway")
+}
+
+type vpcFirewallResult struct {
+	EdgeRouteTable         *ec2.RouteTable
+	Firewall               *networkfirewall.Firewall
+	FirewallPolicy         *networkfirewall.FirewallPolicy
+	RuleGroup              *networkfirewall.RuleGroup
+	RouteTableAssociations []*ec2.RouteTableAssociation
+	RouteTables            []*ec2.RouteTable
+	Routes                 []*ec2.Route
+	SubnetIDs              []pulumi.IDOutput
+	Subnets                []*ec2.Subnet
+}
+
+// EndpointID returns the ID of the firewall endpoint in the given availability zone.
+func (f *vpcFirewallResult) EndpointID(availabilityZone string) pulumi.StringOutput {
+	return f.Firewall.FirewallStatuses.ApplyT(func(statuses []networkfirewall.FirewallFirewallStatus) (string, error) {
+		for _, status := range statuses {
+			for _, syncState := range status.SyncStates {
+				if syncState.AvailabilityZone == nil || *syncState.AvailabilityZone != availabilityZone {
+					continue
+				}
+
+				for _, attachment := range syncState.Attachments {
+					if attachment.EndpointId != nil {
+						return *attachment.EndpointId, nil
+					}
+				}
+			}
+		}
+
+		return "", fmt.Errorf("the firewall has no endpoint in availability zone %s", availabilityZone)
+	}).(pulumi.StringOutput)
+}
+
+// vpcFirewall creates the firewall subnets, which route to the Internet Gateway, and a Network Firewall
+// with an endpoint in each of them. An edge route table is associated with the Internet Gateway so
+// that inbound traffic for the public subnets can be sent through the endpoint of their availability
+// zone, mirroring the outbound routes of the public subnets.
+func vpcFirewall(ctx *pulumi.Context, name string, inputs *vpcFirewallInput, specs []subnetSpec, vpcID pulumi.IDOutput, igw *ec2.InternetGateway, opts ...pulumi.ResourceOption) (*vpcFirewallResult, error) {
+	result := &vpcFirewallResult{}
+
+	tags := map[string]string{
+		"Name": name,
+	}
+	for tagKey, tagValue := range inputs.Tags {
+		tags[tagKey] = tagValue
+	}
+
+	var subnetMappings networkfirewall.FirewallSubnetMappingArray
+	for _, spec := range specs {
+		if !spec.IsFirewall() {
+			continue
+		}
+
+		subnet, err := ec2.NewSubnet(ctx, spec.SubnetName, &ec2.SubnetArgs{
+			VpcId:            vpcID,
+			AvailabilityZone: pulumi.String(spec.AzName),
+			CidrBlock:        pulumi.String(spec.CidrBlock),
+			Tags: pulumi.ToStringMap(map[string]string{
+				"Name": spec.SubnetName,
+			}),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Subnets = append(result.Subnets, subnet)
+		result.SubnetIDs = append(result.SubnetIDs, subnet.ID())
+		subnetMappings = append(subnetMappings, &networkfirewall.FirewallSubnetMappingArgs{
+			SubnetId: subnet.ID(),
+		})
+
+		routeTable, err := ec2.NewRouteTable(ctx, spec.SubnetName, &ec2.RouteTableArgs{
+			VpcId: vpcID,
+			Tags: pulumi.ToStringMap(map[string]string{
+				"Name": spec.SubnetName,
+			}),
+		}, pulumi.Parent(subnet), pulumi.DependsOn([]pulumi.Resource{subnet}))
+		if err != nil {
+			return nil, err
+		}
+
+		result.RouteTables = append(result.RouteTables, routeTable)
+
+		routeTableAssoc, err := ec2.NewRouteTableAssociation(ctx, spec.SubnetName, &ec2.RouteTableAssociationArgs{
+			RouteTableId: routeTable.ID(),
+			SubnetId:     subnet.ID(),
+		}, pulumi.Parent(routeTable), pulumi.DependsOn([]pulumi.Resource{routeTable}))
+		if err != nil {
+			return nil, err
+		}
+
+		result.RouteTableAssociations = append(result.RouteTableAssociations, routeTableAssoc)
+
+		route, err := ec2.NewRoute(ctx, spec.SubnetName, &ec2.RouteArgs{
+			RouteTableId:         routeTable.ID(),
+			GatewayId:            igw.ID(),
+			DestinationCidrBlock: pulumi.String("0.0.0.0/0"),
+		}, pulumi.Parent(routeTable), pulumi.DependsOn([]pulumi.Resource{routeTable}))
+		if err != nil {
+			return nil, err
+		}
+
+		result.Routes = append(result.Routes, route)
+	}
+
+	firewallPolicyArn := pulumi.String(inputs.FirewallPolicyArn).ToStringOutput()
+	if inputs.FirewallPolicyArn == "" {
+		// Only HTTP and TLS traffic to the allowed domains is let through. Other traffic is passed by
+		// the stateful engine's default action.
+		ruleGroup, err := networkfirewall.NewRuleGroup(ctx, name, &networkfirewall.RuleGroupArgs{
+			Capacity: pulumi.Int(100),
+			Type:     pulumi.String("STATEFUL"),
+			RuleGroup: &networkfirewall.RuleGroupRuleGroupArgs{
+				RulesSource: &networkfirewall.RuleGroupRuleGroupRulesSourceArgs{
+					RulesSourceList: &networkfirewall.RuleGroupRuleGroupRulesSourceRulesSourceListArgs{
+						GeneratedRulesType: pulumi.String("ALLOWLIST"),
+						TargetTypes:        pulumi.ToStringArray([]string{"HTTP_HOST", "TLS_SNI"}),
+						Targets:            pulumi.ToStringArray(inputs.AllowedDomains),
+					},
+				},
+			},
+			Tags: pulumi.ToStringMap(tags),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		firewallPolicy, err := networkfirewall.NewFirewallPolicy(ctx, name, &networkfirewall.FirewallPolicyArgs{
+			FirewallPolicy: &networkfirewall.FirewallPolicyFirewallPolicyArgs{
+				StatelessDefaultActions:         pulumi.ToStringArray([]string{"aws:forward_to_sfe"}),
+				StatelessFragmentDefaultActions: pulumi.ToStringArray([]string{"aws:forward_to_sfe"}),
+				StatefulRuleGroupReferences: networkfirewall.FirewallPolicyFirewallPolicyStatefulRuleGroupReferenceArray{
+					&networkfirewall.FirewallPolicyFirewallPolicyStatefulRuleGroupReferenceArgs{
+						ResourceArn: ruleGroup.Arn,
+					},
+				},
+			},
+			Tags: pulumi.ToStringMap(tags),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.RuleGroup = ruleGroup
+		result.FirewallPolicy = firewallPolicy
+		firewallPolicyArn = firewallPolicy.Arn
+	}
+
+	firewall, err := networkfirewall.NewFirewall(ctx, name, &networkfirewall.FirewallArgs{
+		VpcId:             vpcID,
+		FirewallPolicyArn: firewallPolicyArn,
+		DeleteProtection:  pulumi.BoolPtr(inputs.DeleteProtection),
+		SubnetMappings:    subnetMappings,
+		Tags:              pulumi.ToStringMap(tags),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Firewall = firewall
+
+	edgeRouteTableName := fmt.Sprintf("%s-igw", name)
+	edgeRouteTable, err := ec2.NewRouteTable(ctx, edgeRouteTableName, &ec2.RouteTableArgs{
+		VpcId: vpcID,
+		Tags: pulumi.ToStringMap(map[string]string{
+			"Name": edgeRouteTableName,
+		}),
+	}, pulumi.Parent(igw), pulumi.DependsOn([]pulumi.Resource{igw}))
+	if err != nil {
+		return nil, err
+	}
+
+	result.EdgeRouteTable = edgeRouteTable
+	result.RouteTables = append(result.RouteTables, edgeRouteTable)
+
+	edgeRouteTableAssoc, err := ec2.NewRouteTableAssociation(ctx, edgeRouteTableName, &ec2.RouteTableAssociationArgs{
+		RouteTableId: edgeRouteTable.ID(),
+		GatewayId:    igw.ID(),
+	}, pulumi.Parent(edgeRouteTable), pulumi.DependsOn([]pulumi.Resource{edgeRouteTable}))
+	if err != nil {
+		return nil, err
+	}
+
+	result.RouteTableAssociations = append(result.RouteTableAssociations, edgeRouteTableAssoc)
+
+	return result, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2016-2022, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ram"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func validateSubnetSharing(inputs *subnetSharingInput) error {
+	if inputs == nil {
+		return nil
+	}
+
+	if len(inputs.Principals) == 0 {
+		return fmt.Errorf("At least one of [subnetSharing] [principals] must be specified")
+	}
+
+	for _, subnetType := range inputs.SubnetTypes {
+		switch strings.ToLower(subnetType) {
+		case "public", "private", "isolated":
+		default:
+			return fmt.Errorf("Unknown subnet type %s in [subnetSharing] [subnetTypes]", subnetType)
+		}
+	}
+
+	return nil
+}
+
+// Shares returns true if subnets of the given type are shared. All types are shared when no subnet
+// types are configured.
+func (s *subnetSharingInput) Shares(spec subnetSpec) bool {
+	if len(s.SubnetTypes) == 0 {
+		return true
+	}
+
+	for _, subnetType := range s.SubnetTypes {
+		if strings.ToLower(subnetType) == strings.ToLower(spec.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// vpcSubnetShare shares the given subnets with the configured accounts, organizations and
+// organizational units through a RAM resource share.
+func vpcSubnetShare(ctx *pulumi.Context, name string, inputs *subnetSharingInput, subnets []*ec2.Subnet, opts ...pulumi.ResourceOption) (*ram.ResourceShare, error) {
+	shareName := inputs.Name
+	if shareName == "" {
+		shareName = name
+	}
+
+	tags := map[string]string{
+		"Name": shareName,
+	}
+	for tagKey, tagValue := range inputs.Tags {
+		tags[tagKey] = tagValue
+	}
+
+	resourceShare, err := ram.NewResourceShare(ctx, name, &ram.ResourceShareArgs{
+		Name:                    pulumi.String(shareName),
+		AllowExternalPrincipals: pulumi.BoolPtr(inputs.AllowExternalPrincipals),
+		Tags:                    pulumi.ToStringMap(tags),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, subnet := range subnets {
+		_, err := ram.NewResourceAssociation(ctx, fmt.Sprintf("%s-subnet-%v", name, i+1), &ram.ResourceAssociationArgs{
+			ResourceArn:      subnet.Arn,
+			ResourceShareArn: resourceShare.Arn,
+		}, pulumi.Parent(resourceShare), pulumi.DependsOn([]pulumi.Resource{resourceShare, subnet}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, principal := range inputs.Principals {
+		_, err := ram.NewPrincipalAssociation(ctx, fmt.Sprintf("%s-principal-%v", name, i+1), &ram.PrincipalAssociationArgs{
+			Principal:        pulumi.String(principal),
+			ResourceShareArn: resourceShare.Arn,
+		}, pulumi.Parent(resourceShare), pulumi.DependsOn([]pulumi.Resource{resourceShare}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resourceShare, nil
+}
@@ -18,6 +18,9 @@ import (
 	"strings"
 
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/networkfirewall"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ram"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/route53"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -34,9 +37,17 @@ type vpcEndpointSpecsInput struct {
 }
 
 type subnetSpecInput struct {
-	CIDRMask int    `pulumi:"cidrMask"`
-	Name     string `pulumi:"name"`
-	Type     string `pulumi:"type"`
+	CIDRMask      int    `pulumi:"cidrMask"`
+	LocalZoneName string `pulumi:"localZoneName"`
+	Name          string `pulumi:"name"`
+	OutpostArn    string `pulumi:"outpostArn"`
+	Type          string `pulumi:"type"`
+}
+
+// IsEdge returns true if the subnet is placed in a Local Zone or on an Outpost instead of being
+// repeated in every availability zone of the VPC.
+func (s subnetSpecInput) IsEdge() bool {
+	return s.LocalZoneName != "" || s.OutpostArn != ""
 }
 
 func (s subnetSpecInput) IsPublic() bool {
@@ -52,10 +63,22 @@ func (s subnetSpecInput) IsIsolated() bool {
 }
 
 type subnetSpec struct {
-	CidrBlock  string
-	Type       string
-	AzName     string
-	SubnetName string
+	CidrBlock     string
+	Type          string
+	AzName        string
+	SubnetName    string
+	LocalZoneName string
+	OutpostArn    string
+	ParentAzName  string
+}
+
+func (s subnetSpec) IsEdge() bool {
+	return s.LocalZoneName != "" || s.OutpostArn != ""
+}
+
+// IsFirewall returns true for the subnets that host the endpoints of the VPC's Network Firewall.
+func (s subnetSpec) IsFirewall() bool {
+	return strings.ToLower(s.Type) == "firewall"
 }
 
 func (s subnetSpec) IsPublic() bool {
@@ -75,14 +98,50 @@ type natGatewayInput struct {
 	Strategy               string   `pulumi:"strategy"`
 }
 
+type privateHostedZoneInput struct {
+	Comment      string            `pulumi:"comment"`
+	ForceDestroy bool              `pulumi:"forceDestroy"`
+	Name         string            `pulumi:"name"`
+	Tags         map[string]string `pulumi:"tags"`
+	ZoneID       string            `pulumi:"zoneId"`
+}
+
+type dhcpOptionsInput struct {
+	DomainName         string            `pulumi:"domainName"`
+	DomainNameServers  []string          `pulumi:"domainNameServers"`
+	NetbiosNameServers []string          `pulumi:"netbiosNameServers"`
+	NetbiosNodeType    string            `pulumi:"netbiosNodeType"`
+	NtpServers         []string          `pulumi:"ntpServers"`
+	Tags               map[string]string `pulumi:"tags"`
+}
+
+type subnetSharingInput struct {
+	AllowExternalPrincipals bool              `pulumi:"allowExternalPrincipals"`
+	Name                    string            `pulumi:"name"`
+	Principals              []string          `pulumi:"principals"`
+	SubnetTypes             []string          `pulumi:"subnetTypes"`
+	Tags                    map[string]string `pulumi:"tags"`
+}
+
+type vpcFirewallInput struct {
+	AllowedDomains    []string          `pulumi:"allowedDomains"`
+	CIDRMask          int               `pulumi:"cidrMask"`
+	DeleteProtection  bool              `pulumi:"deleteProtection"`
+	FirewallPolicyArn string            `pulumi:"firewallPolicyArn"`
+	Tags              map[string]string `pulumi:"tags"`
+}
+
 type VPCArgs struct {
 	AssignGeneratedIpv6CidrBlock    bool                    `pulumi:"assignGeneratedIpv6CidrBlock"`
 	AvailabilityZoneNames           []string                `pulumi:"availabilityZoneNames"`
 	CIDRBlock                       string                  `pulumi:"cidrBlock"`
+	DefaultResourcesMode            string                  `pulumi:"defaultResourcesMode"`
+	DHCPOptions                     *dhcpOptionsInput       `pulumi:"dhcpOptions"`
 	EnableClassiclink               bool                    `pulumi:"enableClassiclink"`
 	EnableClassiclinkDNSSupport     bool                    `pulumi:"enableClassiclinkDnsSupport"`
 	EnableDNSHostnames              bool                    `pulumi:"enableDnsHostnames"`
 	EnableDNSSuport                 bool                    `pulumi:"enableDnsSupport"`
+	Firewall                        *vpcFirewallInput       `pulumi:"firewall"`
 	InstanceTenancy                 string                  `pulumi:"instanceTenancy"`
 	Ipv4IpamPoolId                  string                  `pulumi:"ipv4IpamPoolId"`
 	Ipv4NetmaskLength               int                     `pulumi:"ipv4NetmaskLength"`
@@ -92,6 +151,8 @@ type VPCArgs struct {
 	Ipv6NetmaskLength               int                     `pulumi:"ipv6NetmaskLength"`
 	NatGateways                     natGatewayInput         `pulumi:"natGateways"`
 	NumberOfAvailabilityZones       int                     `pulumi:"numberOfAvailabilityZones"`
+	PrivateHostedZone               *privateHostedZoneInput `pulumi:"privateHostedZone"`
+	SubnetSharing                   *subnetSharingInput     `pulumi:"subnetSharing"`
 	SubnetSpecs                     []subnetSpecInput       `pulumi:"subnetSpecs"`
 	Tags                            map[string]string       `pulumi:"tags"`
 	VpcEndpointSpecs                []vpcEndpointSpecsInput `pulumi:"vpcEndpointSpecs"`
@@ -100,17 +161,31 @@ type VPCArgs struct {
 type VPCOutput struct {
 	pulumi.ResourceState
 
-	EIPS                   []*ec2.Eip                   `pulumi:"eips"`
-	InternetGateway        *ec2.InternetGateway         `pulumi:"internetGateway"`
-	NatGateways            []*ec2.NatGateway            `pulumi:"natGateways"`
-	RouteTableAssociations []*ec2.RouteTableAssociation `pulumi:"routeTableAssociations"`
-	RouteTables            []*ec2.RouteTable            `pulumi:"routeTables"`
-	Routes                 []*ec2.Route                 `pulumi:"routes"`
-	Subnets                []*ec2.Subnet                `pulumi:"subnets"`
-	VPC                    *ec2.Vpc                     `pulumi:"vpc"`
-	VPCEndpoints           []*ec2.VpcEndpoint           `pulumi:"vpcEndpoints"`
-	VPCID                  pulumi.IDOutput              `pulumi:"vpcId"`
-	PublicSubnetIDs        pulumi.IDArrayOutput         `pulumi:"publicSubnetIds"`
-	PrivateSubnetIDs       pulumi.IDArrayOutput         `pulumi:"privateSubnetIds"`
-	IsolatedSubnetIDs      pulumi.IDArrayOutput         `pulumi:"isolatedSubnetIds"`
+	DefaultNetworkACL      *ec2.DefaultNetworkAcl          `pulumi:"defaultNetworkAcl"`
+	DefaultNetworkACLID    pulumi.StringOutput             `pulumi:"defaultNetworkAclId"`
+	DefaultSecurityGroup   *ec2.DefaultSecurityGroup       `pulumi:"defaultSecurityGroup"`
+	DefaultSecurityGroupID pulumi.StringOutput             `pulumi:"defaultSecurityGroupId"`
+	DHCPOptions            *ec2.VpcDhcpOptions             `pulumi:"dhcpOptions"`
+	EIPS                   []*ec2.Eip                      `pulumi:"eips"`
+	Firewall               *networkfirewall.Firewall       `pulumi:"firewall"`
+	FirewallPolicy         *networkfirewall.FirewallPolicy `pulumi:"firewallPolicy"`
+	FirewallRuleGroup      *networkfirewall.RuleGroup      `pulumi:"firewallRuleGroup"`
+	FirewallSubnetIDs      pulumi.IDArrayOutput            `pulumi:"firewallSubnetIds"`
+	InternetGateway        *ec2.InternetGateway            `pulumi:"internetGateway"`
+	NatGateways            []*ec2.NatGateway               `pulumi:"natGateways"`
+	NetworkACL             *ec2.NetworkAcl                 `pulumi:"networkAcl"`
+	PrivateHostedZone      *route53.Zone                   `pulumi:"privateHostedZone"`
+	PrivateHostedZoneID    pulumi.StringOutput             `pulumi:"privateHostedZoneId"`
+	ResourceShare          *ram.ResourceShare              `pulumi:"resourceShare"`
+	ResourceShareARN       pulumi.StringOutput             `pulumi:"resourceShareArn"`
+	RouteTableAssociations []*ec2.RouteTableAssociation    `pulumi:"routeTableAssociations"`
+	RouteTables            []*ec2.RouteTable               `pulumi:"routeTables"`
+	Routes                 []*ec2.Route                    `pulumi:"routes"`
+	Subnets                []*ec2.Subnet                   `pulumi:"subnets"`
+	VPC                    *ec2.Vpc                        `pulumi:"vpc"`
+	VPCEndpoints           []*ec2.VpcEndpoint              `pulumi:"vpcEndpoints"`
+	VPCID                  pulumi.IDOutput                 `pulumi:"vpcId"`
+	PublicSubnetIDs        pulumi.IDArrayOutput            `pulumi:"publicSubnetIds"`
+	PrivateSubnetIDs       pulumi.IDArrayOutput            `pulumi:"privateSubnetIds"`
+	IsolatedSubnetIDs      pulumi.IDArrayOutput            `pulumi:"isolatedSubnetIds"`
 }